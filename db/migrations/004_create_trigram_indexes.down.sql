@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_people_name_trgm;
+DROP INDEX IF EXISTS idx_people_surname_trgm;
+DROP INDEX IF EXISTS idx_people_patronymic_trgm;
+DROP EXTENSION IF EXISTS pg_trgm;
\ No newline at end of file
@@ -0,0 +1,5 @@
+ALTER TABLE people ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    to_tsvector('simple', coalesce(name, '') || ' ' || coalesce(surname, '') || ' ' || coalesce(patronymic, '')) ||
+    to_tsvector('russian', coalesce(name, '') || ' ' || coalesce(surname, '') || ' ' || coalesce(patronymic, ''))
+) STORED;
+CREATE INDEX idx_people_search_vector ON people USING GIN (search_vector);
\ No newline at end of file
@@ -0,0 +1,10 @@
+CREATE TABLE saved_searches (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(128) NOT NULL,
+    owner VARCHAR(128) NOT NULL,
+    query TEXT NOT NULL DEFAULT '',
+    filters JSONB NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_saved_searches_owner ON saved_searches (owner);
\ No newline at end of file
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_people_deleted_at;
+ALTER TABLE people DROP COLUMN IF EXISTS deleted_at;
\ No newline at end of file
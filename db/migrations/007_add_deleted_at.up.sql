@@ -0,0 +1,2 @@
+ALTER TABLE people ADD COLUMN deleted_at TIMESTAMP;
+CREATE INDEX idx_people_deleted_at ON people (deleted_at) WHERE deleted_at IS NOT NULL;
\ No newline at end of file
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS people_history_trigger ON people;
+DROP FUNCTION IF EXISTS record_people_history();
+DROP TABLE IF EXISTS people_history;
\ No newline at end of file
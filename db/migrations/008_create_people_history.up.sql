@@ -0,0 +1,41 @@
+CREATE TABLE people_history (
+    id BIGSERIAL PRIMARY KEY,
+    person_id INT NOT NULL,
+    version INT NOT NULL,
+    operation VARCHAR(16) NOT NULL,
+    actor VARCHAR(128),
+    before JSONB,
+    after JSONB,
+    changed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_people_history_person_id ON people_history (person_id, id);
+
+-- Автор изменения передаётся приложением через SET LOCAL app.actor в той же транзакции
+CREATE FUNCTION record_people_history() RETURNS trigger AS $$
+DECLARE
+    op VARCHAR(16) := lower(TG_OP);
+BEGIN
+    IF TG_OP = 'UPDATE' AND OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL THEN
+        op := 'delete';
+    ELSIF TG_OP = 'UPDATE' AND OLD.deleted_at IS NOT NULL AND NEW.deleted_at IS NULL THEN
+        op := 'restore';
+    ELSIF TG_OP = 'DELETE' THEN
+        op := 'purge';
+    END IF;
+
+    IF TG_OP = 'DELETE' THEN
+        INSERT INTO people_history (person_id, version, operation, actor, before)
+        VALUES (OLD.id, OLD.version, op, NULLIF(current_setting('app.actor', true), ''), to_jsonb(OLD) - 'search_vector');
+    ELSE
+        INSERT INTO people_history (person_id, version, operation, actor, before, after)
+        VALUES (NEW.id, NEW.version, op, NULLIF(current_setting('app.actor', true), ''),
+                CASE WHEN TG_OP = 'UPDATE' THEN to_jsonb(OLD) - 'search_vector' END,
+                to_jsonb(NEW) - 'search_vector');
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER people_history_trigger
+    AFTER INSERT OR UPDATE OR DELETE ON people
+    FOR EACH ROW EXECUTE FUNCTION record_people_history();
\ No newline at end of file
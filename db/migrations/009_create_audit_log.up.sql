@@ -0,0 +1,31 @@
+CREATE TABLE audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    actor VARCHAR(128),
+    action VARCHAR(64) NOT NULL,
+    target_id INT,
+    request_id VARCHAR(128),
+    client_ip VARCHAR(64),
+    status INT NOT NULL,
+    outcome VARCHAR(16) NOT NULL,
+    prev_hash CHAR(64),
+    hash CHAR(64)
+);
+CREATE INDEX idx_audit_log_target_id ON audit_log (target_id, id);
+CREATE INDEX idx_audit_log_actor ON audit_log (actor, id);
+CREATE INDEX idx_audit_log_created_at ON audit_log (created_at);
+
+-- Журнал аудита только дополняется: изменение и удаление записей запрещены
+CREATE FUNCTION forbid_audit_log_change() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_append_only
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION forbid_audit_log_change();
+
+CREATE TRIGGER audit_log_no_truncate
+    BEFORE TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION forbid_audit_log_change();
\ No newline at end of file
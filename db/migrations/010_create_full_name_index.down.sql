@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_people_full_name_trgm;
\ No newline at end of file
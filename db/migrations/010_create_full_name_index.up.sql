@@ -0,0 +1,3 @@
+CREATE INDEX idx_people_full_name_trgm ON people USING GIN (
+    lower(name || ' ' || surname || ' ' || COALESCE(patronymic, '')) gin_trgm_ops
+) WHERE deleted_at IS NULL;
\ No newline at end of file
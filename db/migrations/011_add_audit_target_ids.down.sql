@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_audit_log_target_ids;
+ALTER TABLE audit_log DROP COLUMN IF EXISTS target_ids;
\ No newline at end of file
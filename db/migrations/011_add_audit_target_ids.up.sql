@@ -0,0 +1,2 @@
+ALTER TABLE audit_log ADD COLUMN target_ids INT[];
+CREATE INDEX idx_audit_log_target_ids ON audit_log USING GIN (target_ids);
\ No newline at end of file
@@ -4,7 +4,10 @@ import (
 	"github.com/joho/godotenv"
 	"os"
 	"people-credentials-api/pkg/logger"
+	"slices"
+	"strconv"
 	"sync"
+	"time"
 )
 
 var (
@@ -12,16 +15,46 @@ var (
 	cfg  *Config
 )
 
+// Политики обработки возможных дубликатов при создании записи
+const (
+	DuplicatePolicyReject = "reject"
+	DuplicatePolicyWarn   = "warn"
+	DuplicatePolicyAllow  = "allow"
+)
+
+// Допустимый порог сходства полных имён для поиска дубликатов. Кандидаты отбираются оператором
+// pg_trgm % с его порогом 0.3 по умолчанию, поэтому меньший порог молча заменялся бы на 0.3
+const (
+	MinDuplicateThreshold = 0.3
+	MaxDuplicateThreshold = 1.0
+)
+
 // Config - структура для хранения конфигурации сервиса
 type Config struct {
-	ServerPort      string
-	DatabasePort    string
-	DatabaseUser    string
-	DatabasePass    string
-	DatabaseName    string
-	DatabaseHost    string
-	DatabaseSSLMode string
-	LogLevel        string
+	ServerPort         string
+	DatabasePort       string
+	DatabaseUser       string
+	DatabasePass       string
+	DatabaseName       string
+	DatabaseHost       string
+	DatabaseSSLMode    string
+	LogLevel           string
+	RequestTimeout     time.Duration
+	RequireIfMatch     bool
+	MaxPageSize        int
+	FullTextConfig     string
+	ExportTimeout      time.Duration
+	ImportTimeout      time.Duration
+	ImportMaxRows      int
+	ImportMaxBytes     int
+	BulkMaxRows        int
+	AdminAPIKey        string
+	DeleteRetention    time.Duration
+	PurgeInterval      time.Duration
+	AuditEnabled       bool
+	AuditHashChain     bool
+	DuplicatePolicy    string
+	DuplicateThreshold float64
 }
 
 // Get загружает конфигурацию из переменных окружения (только при первом вызове)
@@ -43,11 +76,29 @@ func Get() *Config {
 			ServerPort:      getEnv("PEOPLE_CREDENTIALS_SERVER_PORT", "8080", os.LookupEnv),
 			DatabasePort:    getEnv("PEOPLE_CREDENTIALS_DATABASE_PORT", "5432", os.LookupEnv),
 			DatabaseUser:    getEnv("PEOPLE_CREDENTIALS_DATABASE_USER", "postgres", os.LookupEnv),
-			DatabasePass:    getEnv("PEOPLE_CREDENTIALS_DATABASE_PASSWORD", "password", os.LookupEnv),
+			DatabasePass:    getEnvSecret("PEOPLE_CREDENTIALS_DATABASE_PASSWORD", "password", os.LookupEnv),
 			DatabaseName:    getEnv("PEOPLE_CREDENTIALS_DATABASE_NAME", "user_creds_db", os.LookupEnv),
 			DatabaseHost:    getEnv("PEOPLE_CREDENTIALS_DATABASE_HOST", "localhost", os.LookupEnv),
 			DatabaseSSLMode: getEnv("PEOPLE_CREDENTIALS_DATABASE_SSL_MODE", "disable", os.LookupEnv),
 			LogLevel:        getEnv("PEOPLE_CREDENTIALS_LOG_LEVEL", "info", os.LookupEnv),
+			RequestTimeout:  getEnvDuration("PEOPLE_CREDENTIALS_REQUEST_TIMEOUT", 10*time.Second, os.LookupEnv),
+			RequireIfMatch:  getEnvBool("PEOPLE_CREDENTIALS_REQUIRE_IF_MATCH", false, os.LookupEnv),
+			MaxPageSize:     getEnvInt("PEOPLE_CREDENTIALS_MAX_PAGE_SIZE", 100, os.LookupEnv),
+			FullTextConfig:  getEnv("PEOPLE_CREDENTIALS_FULL_TEXT_CONFIG", "simple", os.LookupEnv),
+			ExportTimeout:   getEnvDuration("PEOPLE_CREDENTIALS_EXPORT_TIMEOUT", 5*time.Minute, os.LookupEnv),
+			ImportTimeout:   getEnvDuration("PEOPLE_CREDENTIALS_IMPORT_TIMEOUT", 5*time.Minute, os.LookupEnv),
+			ImportMaxRows:   getEnvInt("PEOPLE_CREDENTIALS_IMPORT_MAX_ROWS", 10000, os.LookupEnv),
+			ImportMaxBytes:  getEnvInt("PEOPLE_CREDENTIALS_IMPORT_MAX_BYTES", 32<<20, os.LookupEnv),
+			BulkMaxRows:     getEnvInt("PEOPLE_CREDENTIALS_BULK_MAX_ROWS", 1000, os.LookupEnv),
+			AdminAPIKey:     getEnvSecret("PEOPLE_CREDENTIALS_ADMIN_API_KEY", "", os.LookupEnv),
+			DeleteRetention: getEnvDuration("PEOPLE_CREDENTIALS_DELETE_RETENTION", 30*24*time.Hour, os.LookupEnv),
+			PurgeInterval:   getEnvDuration("PEOPLE_CREDENTIALS_PURGE_INTERVAL", time.Hour, os.LookupEnv),
+			AuditEnabled:    getEnvBool("PEOPLE_CREDENTIALS_AUDIT_ENABLED", true, os.LookupEnv),
+			AuditHashChain:  getEnvBool("PEOPLE_CREDENTIALS_AUDIT_HASH_CHAIN", false, os.LookupEnv),
+			DuplicatePolicy: getEnvOneOf("PEOPLE_CREDENTIALS_DUPLICATE_POLICY", DuplicatePolicyWarn,
+				[]string{DuplicatePolicyReject, DuplicatePolicyWarn, DuplicatePolicyAllow}, os.LookupEnv),
+			DuplicateThreshold: getEnvFloat("PEOPLE_CREDENTIALS_DUPLICATE_THRESHOLD", 0.8,
+				MinDuplicateThreshold, MaxDuplicateThreshold, os.LookupEnv),
 		}
 
 		logger.Info("Configuration successfully loaded and cached")
@@ -70,3 +121,89 @@ func getEnv(key, fallback string, getEnvFunc func(string) (string, bool)) string
 	logger.Warn("Environment variable not found: " + key + ", using fallback: " + fallback)
 	return fallback
 }
+
+// getEnvSecret получает значение секретной переменной окружения, как getEnv,
+// но не записывает в лог ни значение, ни значение по умолчанию.
+func getEnvSecret(key, fallback string, getEnvFunc func(string) (string, bool)) string {
+	logger.Debug("Trying to load environment variable: " + key)
+
+	if value, ok := getEnvFunc(key); ok {
+		logger.Info("Loaded environment variable: " + key + " = " + maskSecret(value))
+		return value
+	}
+
+	logger.Warn("Environment variable not found: " + key + ", using fallback: " + maskSecret(fallback))
+	return fallback
+}
+
+// maskSecret заменяет непустой секрет звёздочками, чтобы в логе было видно только, задан ли он
+func maskSecret(value string) string {
+	if value == "" {
+		return "(empty)"
+	}
+	return "********"
+}
+
+// getEnvDuration получает значение переменной окружения как time.Duration (например "5s", "1m").
+// Если переменная не задана или не разбирается, возвращает значение по умолчанию.
+func getEnvDuration(key string, fallback time.Duration, getEnvFunc func(string) (string, bool)) time.Duration {
+	value := getEnv(key, fallback.String(), getEnvFunc)
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		logger.Warn("Invalid duration in environment variable: " + key + " = " + value + ", using fallback: " + fallback.String())
+		return fallback
+	}
+	return d
+}
+
+// getEnvBool получает значение переменной окружения как bool ("true", "false", "1", "0").
+// Если переменная не задана или не разбирается, возвращает значение по умолчанию.
+func getEnvBool(key string, fallback bool, getEnvFunc func(string) (string, bool)) bool {
+	value := getEnv(key, strconv.FormatBool(fallback), getEnvFunc)
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		logger.Warn("Invalid boolean in environment variable: " + key + " = " + value + ", using fallback: " + strconv.FormatBool(fallback))
+		return fallback
+	}
+	return b
+}
+
+// getEnvInt получает значение переменной окружения как положительное целое число.
+// Если переменная не задана или не разбирается, возвращает значение по умолчанию.
+func getEnvInt(key string, fallback int, getEnvFunc func(string) (string, bool)) int {
+	value := getEnv(key, strconv.Itoa(fallback), getEnvFunc)
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		logger.Warn("Invalid integer in environment variable: " + key + " = " + value + ", using fallback: " + strconv.Itoa(fallback))
+		return fallback
+	}
+	return n
+}
+
+// getEnvFloat получает значение переменной окружения как число в пределах [lo, hi].
+// Если переменная не задана или не разбирается, возвращает значение по умолчанию.
+func getEnvFloat(key string, fallback, lo, hi float64, getEnvFunc func(string) (string, bool)) float64 {
+	value := getEnv(key, strconv.FormatFloat(fallback, 'f', -1, 64), getEnvFunc)
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f < lo || f > hi {
+		logger.Warn("Invalid number in environment variable: " + key + " = " + value + ", using fallback: " + strconv.FormatFloat(fallback, 'f', -1, 64))
+		return fallback
+	}
+	return f
+}
+
+// getEnvOneOf получает значение переменной окружения из списка допустимых.
+// Если переменная не задана или не входит в список, возвращает значение по умолчанию.
+func getEnvOneOf(key, fallback string, allowed []string, getEnvFunc func(string) (string, bool)) string {
+	value := getEnv(key, fallback, getEnvFunc)
+
+	if !slices.Contains(allowed, value) {
+		logger.Warn("Invalid value in environment variable: " + key + " = " + value + ", using fallback: " + fallback)
+		return fallback
+	}
+	return value
+}
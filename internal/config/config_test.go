@@ -1,8 +1,12 @@
 package config
 
 import (
-	"github.com/stretchr/testify/assert"
+	"os"
+	"people-credentials-api/pkg/logger"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
 )
 
 // ------------
@@ -23,7 +27,110 @@ func TestGetEnvEmpty(t *testing.T) {
 	assert.Equal(t, value, "test")
 }
 
-// mockGetEnv возвращает корректные значения ключей SERVER_PORT и DATABASE_NAME а для остальных значений
+// ------------------
+// Тесты getEnvSecret
+// ------------------
+func TestGetEnvSecretDoesNotLogValue(t *testing.T) {
+	logFile, err := os.CreateTemp("", "config-*.log")
+	assert.NoError(t, err)
+	defer os.Remove(logFile.Name())
+	logger.InitializeLoggers("debug", logFile.Name())
+
+	value := getEnvSecret("SERVER_PORT", "fallback-secret", mockGetEnv)
+	fallback := getEnvSecret("DATABASE_USER", "fallback-secret", mockGetEnv)
+
+	logged, err := os.ReadFile(logFile.Name())
+	assert.NoError(t, err)
+	assert.Equal(t, "8888", value)
+	assert.Equal(t, "fallback-secret", fallback)
+	assert.Contains(t, string(logged), "SERVER_PORT = ********")
+	assert.NotContains(t, string(logged), "8888")
+	assert.NotContains(t, string(logged), "fallback-secret")
+}
+
+// --------------------
+// Тесты getEnvDuration
+// --------------------
+func TestGetEnvDurationExists(t *testing.T) {
+	value := getEnvDuration("REQUEST_TIMEOUT", time.Second, mockGetEnv)
+	assert.Equal(t, 3*time.Second, value)
+}
+
+func TestGetEnvDurationDoesNotExists(t *testing.T) {
+	value := getEnvDuration("DATABASE_USER", time.Second, mockGetEnv)
+	assert.Equal(t, time.Second, value)
+}
+
+func TestGetEnvDurationInvalid(t *testing.T) {
+	value := getEnvDuration("DATABASE_NAME", time.Second, mockGetEnv)
+	assert.Equal(t, time.Second, value)
+}
+
+// ----------------
+// Тесты getEnvBool
+// ----------------
+func TestGetEnvBoolExists(t *testing.T) {
+	value := getEnvBool("REQUIRE_IF_MATCH", false, mockGetEnv)
+	assert.True(t, value)
+}
+
+func TestGetEnvBoolInvalid(t *testing.T) {
+	value := getEnvBool("DATABASE_NAME", true, mockGetEnv)
+	assert.True(t, value)
+}
+
+// ---------------
+// Тесты getEnvInt
+// ---------------
+func TestGetEnvIntExists(t *testing.T) {
+	value := getEnvInt("MAX_PAGE_SIZE", 100, mockGetEnv)
+	assert.Equal(t, 50, value)
+}
+
+func TestGetEnvIntInvalid(t *testing.T) {
+	value := getEnvInt("DATABASE_NAME", 100, mockGetEnv)
+	assert.Equal(t, 100, value)
+}
+
+// -----------------
+// Тесты getEnvFloat
+// -----------------
+func TestGetEnvFloatExists(t *testing.T) {
+	value := getEnvFloat("DUPLICATE_THRESHOLD", 0.8, 0.3, 1, mockGetEnv)
+	assert.Equal(t, 0.65, value)
+}
+
+func TestGetEnvFloatOutOfRange(t *testing.T) {
+	value := getEnvFloat("DUPLICATE_THRESHOLD", 0.8, 0.7, 1, mockGetEnv)
+	assert.Equal(t, 0.8, value)
+}
+
+func TestDuplicateThresholdBelowTrigramCutoffFallsBack(t *testing.T) {
+	getEnv := func(string) (string, bool) { return "0.2", true }
+	value := getEnvFloat("DUPLICATE_THRESHOLD", 0.8, MinDuplicateThreshold, MaxDuplicateThreshold, getEnv)
+	assert.Equal(t, 0.8, value)
+}
+
+func TestGetEnvFloatInvalid(t *testing.T) {
+	value := getEnvFloat("DATABASE_NAME", 0.8, 0.3, 1, mockGetEnv)
+	assert.Equal(t, 0.8, value)
+}
+
+// -----------------
+// Тесты getEnvOneOf
+// -----------------
+func TestGetEnvOneOfExists(t *testing.T) {
+	value := getEnvOneOf("DUPLICATE_POLICY", DuplicatePolicyWarn, []string{DuplicatePolicyReject, DuplicatePolicyWarn}, mockGetEnv)
+	assert.Equal(t, DuplicatePolicyReject, value)
+}
+
+func TestGetEnvOneOfInvalid(t *testing.T) {
+	value := getEnvOneOf("DATABASE_NAME", DuplicatePolicyWarn, []string{DuplicatePolicyReject, DuplicatePolicyWarn}, mockGetEnv)
+	assert.Equal(t, DuplicatePolicyWarn, value)
+}
+
+// mockGetEnv возвращает корректные значения ключей SERVER_PORT, DATABASE_NAME, REQUEST_TIMEOUT, REQUIRE_IF_MATCH, MAX_PAGE_SIZE,
+// DUPLICATE_THRESHOLD и DUPLICATE_POLICY а для остальных значений
 // имитирует ненайденное значение
 func mockGetEnv(key string) (string, bool) {
 	if key == "SERVER_PORT" {
@@ -32,5 +139,20 @@ func mockGetEnv(key string) (string, bool) {
 	if key == "DATABASE_NAME" {
 		return "test", true
 	}
+	if key == "REQUEST_TIMEOUT" {
+		return "3s", true
+	}
+	if key == "REQUIRE_IF_MATCH" {
+		return "true", true
+	}
+	if key == "MAX_PAGE_SIZE" {
+		return "50", true
+	}
+	if key == "DUPLICATE_THRESHOLD" {
+		return "0.65", true
+	}
+	if key == "DUPLICATE_POLICY" {
+		return "reject", true
+	}
 	return "", false
 }
@@ -1,49 +1,151 @@
 package enricher
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"people-credentials-api/internal/models"
 	"people-credentials-api/pkg/integrations/agify"
 	"people-credentials-api/pkg/integrations/genderize"
 	"people-credentials-api/pkg/integrations/nationalize"
 	"people-credentials-api/pkg/logger"
+	"sync"
 )
 
-func Enrich(p models.InsertPersonRequest) (models.Person, error) {
+// ErrEnrichmentFailed возвращается, если не удалось получить данные от внешних API
+var ErrEnrichmentFailed = errors.New("enrichment failed")
+
+// prediction - данные, предсказанные внешними API по имени
+type prediction struct {
+	age         int
+	gender      string
+	nationality string
+}
+
+// predictName запрашивает предсказания по имени, подменяется в тестах
+var predictName = predict
+
+// batchWorkers - количество имён, обогащаемых параллельно в EnrichBatch
+const batchWorkers = 8
+
+// Enrich дополняет данные человека возрастом, полом и национальностью из внешних API.
+// Если API не может сделать предсказание по имени, соответствующее поле остаётся пустым.
+func Enrich(ctx context.Context, p models.InsertPersonRequest) (models.Person, error) {
 	logger.Info("Starting enrichment process for: " + p.Name + " " + p.Surname)
-	
-	var result models.Person
-	result.Name = p.Name
-	result.Surname = p.Surname
-	result.Patronymic = p.Patronymic
-
-	logger.Debug("Fetching age from agify for: " + p.Name)
-	age, err := agify.GetAge(p.Name)
+
+	pred, err := predictName(ctx, p.Name)
 	if err != nil {
-		logger.Error("Failed to get age from agify: " + err.Error())
 		return models.Person{}, err
 	}
+
+	logger.Info("Enrichment process completed for: " + p.Name)
+	return enriched(p, pred), nil
+}
+
+// EnrichBatch обогащает список людей. Каждое уникальное имя запрашивается у внешних API один раз,
+// разные имена обрабатываются параллельно. errs[i] содержит ошибку обогащения people[i].
+func EnrichBatch(ctx context.Context, people []models.InsertPersonRequest) (result []models.Person, errs []error) {
+	logger.Info(fmt.Sprintf("Starting batch enrichment of %d persons", len(people)))
+
+	names := []string{}
+	seen := map[string]bool{}
+	for _, p := range people {
+		if !seen[p.Name] {
+			seen[p.Name] = true
+			names = append(names, p.Name)
+		}
+	}
+
+	type outcome struct {
+		pred prediction
+		err  error
+	}
+	outcomes := make([]outcome, len(names))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(batchWorkers, len(names)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				pred, err := predictName(ctx, names[i])
+				outcomes[i] = outcome{pred, err}
+			}
+		}()
+	}
+	for i := range names {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	byName := make(map[string]outcome, len(names))
+	for i, name := range names {
+		byName[name] = outcomes[i]
+	}
+
+	result = make([]models.Person, len(people))
+	errs = make([]error, len(people))
+	for i, p := range people {
+		o := byName[p.Name]
+		if o.err != nil {
+			errs[i] = o.err
+			continue
+		}
+		result[i] = enriched(p, o.pred)
+	}
+
+	logger.Info(fmt.Sprintf("Batch enrichment completed for %d unique names", len(names)))
+	return result, errs
+}
+
+func enriched(p models.InsertPersonRequest, pred prediction) models.Person {
+	return models.Person{
+		Name:        p.Name,
+		Surname:     p.Surname,
+		Patronymic:  p.Patronymic,
+		Age:         pred.age,
+		Gender:      pred.gender,
+		Nationality: pred.nationality,
+	}
+}
+
+// predict запрашивает возраст, пол и национальность по имени
+func predict(ctx context.Context, name string) (prediction, error) {
+	var result prediction
+
+	logger.Debug("Fetching age from agify for: " + name)
+	age, err := agify.GetAge(ctx, name)
+	if errors.Is(err, agify.ErrNoPrediction) {
+		logger.Warn("No age prediction from agify for: " + name + ", leaving it empty")
+	} else if err != nil {
+		logger.Error("Failed to get age from agify: " + err.Error())
+		return prediction{}, fmt.Errorf("%w: %w", ErrEnrichmentFailed, err)
+	}
 	logger.Debug("Received age from agify: " + fmt.Sprintf("%d", age))
-	result.Age = age
+	result.age = age
 
-	logger.Debug("Fetching gender from genderize for: " + p.Name)
-	gender, err := genderize.GetGender(p.Name)
-	if err != nil {
+	logger.Debug("Fetching gender from genderize for: " + name)
+	gender, err := genderize.GetGender(ctx, name)
+	if errors.Is(err, genderize.ErrNoPrediction) {
+		logger.Warn("No gender prediction from genderize for: " + name + ", leaving it empty")
+	} else if err != nil {
 		logger.Error("Failed to get gender from genderize: " + err.Error())
-		return models.Person{}, err
+		return prediction{}, fmt.Errorf("%w: %w", ErrEnrichmentFailed, err)
 	}
 	logger.Debug("Received gender from genderize: " + gender)
-	result.Gender = gender
+	result.gender = gender
 
-	logger.Debug("Fetching nationality from nationalize for: " + p.Name)
-	nationality, err := nationalize.GetNationality(p.Name)
-	if err != nil {
+	logger.Debug("Fetching nationality from nationalize for: " + name)
+	nationality, err := nationalize.GetNationality(ctx, name)
+	if errors.Is(err, nationalize.ErrNoPrediction) {
+		logger.Warn("No nationality prediction from nationalize for: " + name + ", leaving it empty")
+	} else if err != nil {
 		logger.Error("Failed to get nationality from nationalize: " + err.Error())
-		return models.Person{}, err
+		return prediction{}, fmt.Errorf("%w: %w", ErrEnrichmentFailed, err)
 	}
 	logger.Debug("Received nationality from nationalize: " + nationality)
-	result.Nationality = nationality
+	result.nationality = nationality
 
-	logger.Info("Enrichment process completed for: " + p.Name)
 	return result, nil
 }
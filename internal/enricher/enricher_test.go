@@ -0,0 +1,49 @@
+package enricher
+
+import (
+	"context"
+	"errors"
+	"people-credentials-api/internal/models"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// --------------------------
+// Тесты пакетного обогащения
+// --------------------------
+func TestEnrichBatchRequestsEachNameOnce(t *testing.T) {
+	var mu sync.Mutex
+	calls := map[string]int{}
+	predictName = func(_ context.Context, name string) (prediction, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls[name]++
+		if name == "Broken" {
+			return prediction{}, ErrEnrichmentFailed
+		}
+		return prediction{age: len(name), gender: "male", nationality: "RU"}, nil
+	}
+	defer func() { predictName = predict }()
+
+	people, errs := EnrichBatch(context.Background(), []models.InsertPersonRequest{
+		{Name: "Ivan", Surname: "Petrov"},
+		{Name: "Broken", Surname: "Name"},
+		{Name: "Ivan", Surname: "Sidorov", Patronymic: "Petrovich"},
+	})
+
+	assert.Equal(t, map[string]int{"Ivan": 1, "Broken": 1}, calls)
+	assert.Equal(t, models.Person{Name: "Ivan", Surname: "Petrov", Age: 4, Gender: "male", Nationality: "RU"}, people[0])
+	assert.Equal(t, models.Person{Name: "Ivan", Surname: "Sidorov", Patronymic: "Petrovich", Age: 4, Gender: "male", Nationality: "RU"}, people[2])
+	assert.NoError(t, errs[0])
+	assert.True(t, errors.Is(errs[1], ErrEnrichmentFailed))
+	assert.NoError(t, errs[2])
+}
+
+func TestEnrichBatchEmpty(t *testing.T) {
+	people, errs := EnrichBatch(context.Background(), nil)
+
+	assert.Empty(t, people)
+	assert.Empty(t, errs)
+}
@@ -0,0 +1,60 @@
+package filterexpr
+
+// Node - узел дерева разобранного выражения фильтра
+type Node interface {
+	node()
+}
+
+// And - логическое И двух подвыражений
+type And struct {
+	Left, Right Node
+}
+
+// Or - логическое ИЛИ двух подвыражений
+type Or struct {
+	Left, Right Node
+}
+
+// Not - логическое отрицание подвыражения
+type Not struct {
+	Expr Node
+}
+
+// Comparison - сравнение поля со значением. Value уже проверено на соответствие
+// типу поля: int для FieldInteger, time.Time для FieldTime, string для FieldText.
+// Prefix означает, что текстовое значение было задано с завершающей звёздочкой.
+type Comparison struct {
+	Field  string
+	Op     Operator
+	Value  any
+	Prefix bool
+}
+
+func (And) node()        {}
+func (Or) node()         {}
+func (Not) node()        {}
+func (Comparison) node() {}
+
+// Operator - оператор сравнения
+type Operator string
+
+const (
+	OpEq  Operator = ":"
+	OpNe  Operator = "!="
+	OpGt  Operator = ">"
+	OpGte Operator = ">="
+	OpLt  Operator = "<"
+	OpLte Operator = "<="
+)
+
+// FieldType определяет тип значения поля и допустимые для него операторы
+type FieldType int
+
+const (
+	// FieldText поддерживает : (без учёта регистра, * в конце - поиск по префиксу) и !=
+	FieldText FieldType = iota
+	// FieldInteger поддерживает все операторы сравнения
+	FieldInteger
+	// FieldTime поддерживает все операторы сравнения, значение - RFC 3339 или YYYY-MM-DD
+	FieldTime
+)
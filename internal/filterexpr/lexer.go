@@ -0,0 +1,119 @@
+package filterexpr
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenOp
+	tokenLParen
+	tokenRParen
+	tokenAnd
+	tokenOr
+	tokenNot
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	pos   int
+	value string
+}
+
+// SyntaxError описывает ошибку в выражении фильтра с позицией (в символах от начала)
+type SyntaxError struct {
+	Pos int
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("filter syntax error at position %d: %s", e.Pos, e.Msg)
+}
+
+// lex разбивает выражение на токены
+func lex(input string) ([]token, error) {
+	runes := []rune(input)
+	tokens := []token{}
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", pos: i})
+			i++
+		case r == ':':
+			tokens = append(tokens, token{kind: tokenOp, text: ":", pos: i})
+			i++
+		case r == '!' || r == '>' || r == '<':
+			op := string(r)
+			if i+1 < len(runes) && runes[i+1] == '=' {
+				op += "="
+			}
+			if op == "!" {
+				return nil, &SyntaxError{Pos: i, Msg: "expected != operator"}
+			}
+			tokens = append(tokens, token{kind: tokenOp, text: op, pos: i})
+			i += len([]rune(op))
+		case r == '"':
+			start := i
+			var sb strings.Builder
+			i++
+			for {
+				if i >= len(runes) {
+					return nil, &SyntaxError{Pos: start, Msg: "unterminated string"}
+				}
+				if runes[i] == '\\' && i+1 < len(runes) {
+					sb.WriteRune(runes[i+1])
+					i += 2
+					continue
+				}
+				if runes[i] == '"' {
+					i++
+					break
+				}
+				sb.WriteRune(runes[i])
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenString, text: string(runes[start:i]), pos: start, value: sb.String()})
+		case isWordRune(r):
+			// В значении сразу после оператора двоеточие - часть слова,
+			// поэтому время вида 2024-01-01T10:00:00Z можно писать без кавычек
+			value := len(tokens) > 0 && tokens[len(tokens)-1].kind == tokenOp
+			start := i
+			for i < len(runes) && (isWordRune(runes[i]) || value && runes[i] == ':') {
+				i++
+			}
+			word := string(runes[start:i])
+			kind := tokenIdent
+			switch strings.ToUpper(word) {
+			case "AND":
+				kind = tokenAnd
+			case "OR":
+				kind = tokenOr
+			case "NOT":
+				kind = tokenNot
+			}
+			tokens = append(tokens, token{kind: kind, text: word, pos: start, value: word})
+		default:
+			return nil, &SyntaxError{Pos: i, Msg: fmt.Sprintf("unexpected character %q", r)}
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, pos: len(runes)}), nil
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("_-.*+", r)
+}
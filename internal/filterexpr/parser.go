@@ -0,0 +1,187 @@
+package filterexpr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// MaxLength - максимальная длина выражения в символах
+	MaxLength = 1000
+	// maxDepth ограничивает вложенность, чтобы рекурсивный разбор не исчерпал стек
+	maxDepth = 32
+)
+
+// Parse разбирает выражение фильтра вида
+//
+//	(gender:female AND age>=30) OR nationality:KZ
+//
+// и проверяет его по списку разрешённых полей и их типов.
+//
+// Грамматика (AND связывает сильнее OR, ключевые слова без учёта регистра):
+//
+//	expr       = term { OR term }
+//	term       = factor { AND factor }
+//	factor     = NOT factor | "(" expr ")" | comparison
+//	comparison = field op value
+//	op         = ":" | "!=" | ">" | ">=" | "<" | "<="
+//	value      = word | "quoted string"
+func Parse(input string, fields map[string]FieldType) (Node, error) {
+	if utf8.RuneCountInString(input) > MaxLength {
+		return nil, &SyntaxError{Pos: MaxLength, Msg: fmt.Sprintf("expression is longer than %d characters", MaxLength)}
+	}
+
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens, fields: fields}
+	node, err := p.parseOr(0)
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, &SyntaxError{Pos: t.pos, Msg: fmt.Sprintf("unexpected %q", t.text)}
+	}
+	return node, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	fields map[string]FieldType
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) parseOr(depth int) (Node, error) {
+	left, err := p.parseAnd(depth)
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenOr {
+		p.next()
+		right, err := p.parseAnd(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = Or{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd(depth int) (Node, error) {
+	left, err := p.parseFactor(depth)
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenAnd {
+		p.next()
+		right, err := p.parseFactor(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = And{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseFactor(depth int) (Node, error) {
+	if depth > maxDepth {
+		return nil, &SyntaxError{Pos: p.peek().pos, Msg: "expression is nested too deeply"}
+	}
+
+	t := p.peek()
+	switch t.kind {
+	case tokenNot:
+		p.next()
+		expr, err := p.parseFactor(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return Not{Expr: expr}, nil
+	case tokenLParen:
+		p.next()
+		expr, err := p.parseOr(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, &SyntaxError{Pos: closing.pos, Msg: "expected )"}
+		}
+		return expr, nil
+	case tokenIdent:
+		return p.parseComparison()
+	case tokenEOF:
+		return nil, &SyntaxError{Pos: t.pos, Msg: "unexpected end of expression"}
+	}
+	return nil, &SyntaxError{Pos: t.pos, Msg: fmt.Sprintf("unexpected %q", t.text)}
+}
+
+func (p *parser) parseComparison() (Node, error) {
+	field := p.next()
+	fieldType, ok := p.fields[field.value]
+	if !ok {
+		return nil, &SyntaxError{Pos: field.pos, Msg: fmt.Sprintf("unknown field %q", field.value)}
+	}
+
+	opToken := p.next()
+	if opToken.kind != tokenOp {
+		return nil, &SyntaxError{Pos: opToken.pos, Msg: fmt.Sprintf("expected operator after %q", field.value)}
+	}
+	op := Operator(opToken.text)
+
+	valueToken := p.next()
+	if valueToken.kind != tokenIdent && valueToken.kind != tokenString {
+		return nil, &SyntaxError{Pos: valueToken.pos, Msg: fmt.Sprintf("expected value after %s%s", field.value, op)}
+	}
+
+	c := Comparison{Field: field.value, Op: op}
+	switch fieldType {
+	case FieldText:
+		if op != OpEq && op != OpNe {
+			return nil, &SyntaxError{Pos: opToken.pos, Msg: fmt.Sprintf("operator %s is not supported for text field %q", op, field.value)}
+		}
+		value := valueToken.value
+		if valueToken.kind == tokenIdent && strings.HasSuffix(value, "*") {
+			value = strings.TrimSuffix(value, "*")
+			c.Prefix = true
+		}
+		c.Value = value
+	case FieldInteger:
+		n, err := strconv.Atoi(valueToken.value)
+		if err != nil {
+			return nil, &SyntaxError{Pos: valueToken.pos, Msg: fmt.Sprintf("field %q expects an integer", field.value)}
+		}
+		c.Value = n
+	case FieldTime:
+		t, err := parseTime(valueToken.value)
+		if err != nil {
+			return nil, &SyntaxError{Pos: valueToken.pos, Msg: fmt.Sprintf("field %q expects an RFC 3339 timestamp or a YYYY-MM-DD date", field.value)}
+		}
+		c.Value = t
+	}
+	return c, nil
+}
+
+func parseTime(value string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t, err = time.Parse(time.DateOnly, value)
+	}
+	return t, err
+}
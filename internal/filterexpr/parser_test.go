@@ -0,0 +1,115 @@
+package filterexpr
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testFields = map[string]FieldType{
+	"name":       FieldText,
+	"age":        FieldInteger,
+	"created_at": FieldTime,
+}
+
+// -------------------------------
+// Тесты разбора выражений фильтра
+// -------------------------------
+func TestParseComparison(t *testing.T) {
+	node, err := Parse("age>=30", testFields)
+
+	assert.NoError(t, err)
+	assert.Equal(t, Comparison{Field: "age", Op: OpGte, Value: 30}, node)
+}
+
+func TestParsePrecedence(t *testing.T) {
+	node, err := Parse("name:ivan OR name:petr and not age<18", testFields)
+
+	assert.NoError(t, err)
+	assert.Equal(t, Or{
+		Left: Comparison{Field: "name", Op: OpEq, Value: "ivan"},
+		Right: And{
+			Left:  Comparison{Field: "name", Op: OpEq, Value: "petr"},
+			Right: Not{Expr: Comparison{Field: "age", Op: OpLt, Value: 18}},
+		},
+	}, node)
+}
+
+func TestParseParenthesesAndValues(t *testing.T) {
+	node, err := Parse(`(name:iv* OR name:"van \"der\"") AND created_at>2024-01-31`, testFields)
+
+	assert.NoError(t, err)
+	assert.Equal(t, And{
+		Left: Or{
+			Left:  Comparison{Field: "name", Op: OpEq, Value: "iv", Prefix: true},
+			Right: Comparison{Field: "name", Op: OpEq, Value: `van "der"`},
+		},
+		Right: Comparison{Field: "created_at", Op: OpGt, Value: time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)},
+	}, node)
+}
+
+func TestParseUnquotedTimestamp(t *testing.T) {
+	node, err := Parse("created_at>2024-01-01T10:00:00+05:00 AND name:a:b", testFields)
+
+	assert.NoError(t, err)
+	assert.Equal(t, And{
+		Left:  Comparison{Field: "created_at", Op: OpGt, Value: time.Date(2024, 1, 1, 10, 0, 0, 0, time.FixedZone("", 5*60*60))},
+		Right: Comparison{Field: "name", Op: OpEq, Value: "a:b"},
+	}, node)
+}
+
+func TestParseQuotedStarIsLiteral(t *testing.T) {
+	node, err := Parse(`name:"a*"`, testFields)
+
+	assert.NoError(t, err)
+	assert.Equal(t, Comparison{Field: "name", Op: OpEq, Value: "a*"}, node)
+}
+
+func TestParseErrors(t *testing.T) {
+	for input, pos := range map[string]int{
+		"":                0,
+		"salary>10":       0,
+		"age>ten":         4,
+		"name>ivan":       4,
+		"created_at<soon": 11,
+		"age 30":          4,
+		"(age:1":          6,
+		"age:1 age:2":     6,
+		"age:1 AND":       9,
+		`name:"ivan`:      5,
+		"name!ivan":       4,
+		"name:ivan;":      9,
+	} {
+		_, err := Parse(input, testFields)
+
+		var syntaxErr *SyntaxError
+		if assert.True(t, errors.As(err, &syntaxErr), input) {
+			assert.Equal(t, pos, syntaxErr.Pos, input)
+		}
+	}
+}
+
+func TestParseLimits(t *testing.T) {
+	_, err := Parse(strings.Repeat("(", 40)+"age:1"+strings.Repeat(")", 40), testFields)
+	assert.ErrorContains(t, err, "nested too deeply")
+
+	_, err = Parse(strings.Repeat("NOT ", 40)+"age:1", testFields)
+	assert.ErrorContains(t, err, "nested too deeply")
+
+	_, err = Parse("name:"+strings.Repeat("a", MaxLength), testFields)
+	assert.ErrorContains(t, err, "longer than")
+}
+
+func FuzzParse(f *testing.F) {
+	f.Add("(name:iv* OR age>=30) AND NOT created_at<2024-01-01")
+	f.Add(`name:"a\"b"`)
+	f.Fuzz(func(t *testing.T, input string) {
+		node, err := Parse(input, testFields)
+		if err == nil && node == nil {
+			t.Fatalf("nil node without error for %q", input)
+		}
+	})
+}
@@ -1,11 +1,17 @@
 package models
 
+import (
+	"encoding/json"
+	"people-credentials-api/internal/filterexpr"
+	"time"
+)
+
 // InsertPersonRequest represents the request payload for creating a new person.
 // swagger:model
 type InsertPersonRequest struct {
-	Name       string `json:"name"`
-	Surname    string `json:"surname"`
-	Patronymic string `json:"patronymic"`
+	Name       string `json:"name" validate:"required,max=64,alpha"`
+	Surname    string `json:"surname" validate:"required,max=64,alpha"`
+	Patronymic string `json:"patronymic" validate:"max=64,alpha"`
 }
 
 // DeletePersonRequest represents the request payload for deleting a person by ID.
@@ -15,39 +21,350 @@ type DeletePersonRequest struct {
 }
 
 // Person represents a person's complete data.
+// DeletedAt is set for soft-deleted persons, which are visible to administrators only.
 // swagger:model
 type Person struct {
-	ID          int    `json:"id,omitempty"`
-	Name        string `json:"name"`
-	Surname     string `json:"surname"`
-	Patronymic  string `json:"patronymic"`
-	Age         int    `json:"age"`
-	Gender      string `json:"gender"`
-	Nationality string `json:"nationality"`
+	ID          int        `json:"id,omitempty"`
+	Name        string     `json:"name" validate:"required,max=64,alpha"`
+	Surname     string     `json:"surname" validate:"required,max=64,alpha"`
+	Patronymic  string     `json:"patronymic" validate:"max=64,alpha"`
+	Age         int        `json:"age" validate:"min=0,max=150"`
+	Gender      string     `json:"gender" validate:"oneof=male female"`
+	Nationality string     `json:"nationality" validate:"len=2,upper"`
+	Version     int        `json:"version,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
 }
 
-// ErrorResponse represents an error response.
+// Problem represents an error response in RFC 7807 (application/problem+json) format.
+// Code is a stable machine-readable error code, Errors lists field-level validation failures,
+// Duplicates lists the existing persons that caused a duplicate_person error.
 // swagger:model
-type ErrorResponse struct {
-	Error string `json:"error"`
+type Problem struct {
+	Type       string               `json:"type"`
+	Title      string               `json:"title"`
+	Status     int                  `json:"status"`
+	Detail     string               `json:"detail,omitempty"`
+	Instance   string               `json:"instance,omitempty"`
+	Code       string               `json:"code"`
+	RequestID  string               `json:"request_id,omitempty"`
+	Errors     []FieldError         `json:"errors,omitempty"`
+	Duplicates []DuplicateCandidate `json:"duplicates,omitempty"`
+}
+
+// FieldError describes a validation failure of a single request field.
+// swagger:model
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
 }
 
 // Filters represents the filtering criteria for searching persons.
+// Q is a free-text query matched against name, surname and patronymic at once.
+// IsNull and NotNull list optional fields that must be empty or filled respectively.
+// Filter is a boolean filter expression; Expr is its parsed and validated form.
+// Fields limits the columns read for each person, an empty list means all of them.
+// IncludeDeleted also matches soft-deleted persons, which are excluded by default.
+// Pagination fields are not serialized, so a stored Filters value describes only the query itself.
 // swagger:model
 type Filters struct {
-	ID          int
-	Name        string
-	Surname     string
-	Patronymic  string
-	Age         int
-	Gender      string
-	Nationality string
-	Limit       int
-	Offset      int
+	ID              int             `json:"id,omitempty"`
+	IDs             []int           `json:"ids,omitempty"`
+	Q               string          `json:"q,omitempty"`
+	Name            string          `json:"name,omitempty"`
+	NameMatch       MatchMode       `json:"name_match,omitempty"`
+	Surname         string          `json:"surname,omitempty"`
+	SurnameMatch    MatchMode       `json:"surname_match,omitempty"`
+	Patronymic      string          `json:"patronymic,omitempty"`
+	PatronymicMatch MatchMode       `json:"patronymic_match,omitempty"`
+	Age             int             `json:"age,omitempty"`
+	Gender          string          `json:"gender,omitempty"`
+	Nationality     string          `json:"nationality,omitempty"`
+	AgeMin          *int            `json:"age_min,omitempty"`
+	AgeMax          *int            `json:"age_max,omitempty"`
+	Genders         []string        `json:"genders,omitempty"`
+	Nationalities   []string        `json:"nationalities,omitempty"`
+	CreatedAfter    time.Time       `json:"created_after,omitzero"`
+	CreatedBefore   time.Time       `json:"created_before,omitzero"`
+	IsNull          []string        `json:"is_null,omitempty"`
+	NotNull         []string        `json:"not_null,omitempty"`
+	Filter          string          `json:"filter,omitempty"`
+	Expr            filterexpr.Node `json:"-"`
+	IncludeDeleted  bool            `json:"include_deleted,omitempty"`
+	Fields          []string        `json:"fields,omitempty"`
+	Sort            []SortField     `json:"sort,omitempty"`
+	After           *Cursor         `json:"-"`
+	Limit           int             `json:"-"`
+	Offset          int             `json:"-"`
+}
+
+// MatchMode defines how a text filter value is compared with a column.
+// The zero value behaves as MatchContains.
+type MatchMode string
+
+const (
+	MatchContains MatchMode = "contains"
+	MatchExact    MatchMode = "exact"
+	MatchPrefix   MatchMode = "prefix"
+	MatchFuzzy    MatchMode = "fuzzy"
+)
+
+// SortField represents a single sort key of a search request.
+type SortField struct {
+	Field string `json:"field"`
+	Desc  bool   `json:"desc,omitempty"`
+}
+
+// Cursor represents a keyset pagination position: the sort key of the last row of the previous page.
+// Sort identifies the sort order the cursor was issued for, Values hold the sort key values except ID.
+type Cursor struct {
+	Sort   string   `json:"s,omitempty"`
+	Values []string `json:"v,omitempty"`
+	ID     int      `json:"id"`
 }
 
 // SearchResponse represents the response payload for search results.
 // swagger:model
 type SearchResponse struct {
-	Persons []Person `json:"persons"`
+	Persons    []Person `json:"persons"`
+	Total      int      `json:"total"`
+	Page       int      `json:"page,omitempty"`
+	PageSize   int      `json:"page_size"`
+	HasNext    bool     `json:"has_next"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+}
+
+// SavedSearchRequest represents the request payload for creating or updating a saved search.
+// Query holds search parameters in the same format as the query string of GET /api/v1/search,
+// e.g. "gender=female&age_min=30&sort=-age". Pagination parameters are ignored.
+// swagger:model
+type SavedSearchRequest struct {
+	Name  string `json:"name" validate:"required,max=128"`
+	Query string `json:"query"`
+}
+
+// SavedSearch represents a named search preset that can be shared and rerun.
+// Invalid explains why the stored filters can no longer be applied;
+// such a search can be updated or deleted but not run.
+// swagger:model
+type SavedSearch struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Owner     string    `json:"owner"`
+	Query     string    `json:"query"`
+	Filters   Filters   `json:"filters"`
+	Invalid   string    `json:"invalid,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SavedSearchPage represents a page of saved searches ordered by ID.
+// swagger:model
+type SavedSearchPage struct {
+	SavedSearches []SavedSearch `json:"saved_searches"`
+	Page          int           `json:"page"`
+	PageSize      int           `json:"page_size"`
+	HasNext       bool          `json:"has_next"`
+}
+
+// StatsSummary represents overall statistics of persons matching the filters.
+// Age aggregates take into account only persons with a known age.
+// swagger:model
+type StatsSummary struct {
+	Total      int      `json:"total"`
+	WithAge    int      `json:"with_age"`
+	AverageAge *float64 `json:"average_age"`
+	MinAge     *int     `json:"min_age"`
+	MaxAge     *int     `json:"max_age"`
+}
+
+// GroupStats represents counts of persons grouped by a field value.
+// Persons without a value are reported under the "unknown" key.
+// swagger:model
+type GroupStats struct {
+	GroupBy string        `json:"group_by"`
+	Total   int           `json:"total"`
+	Buckets []StatsBucket `json:"buckets"`
+}
+
+// StatsBucket represents a single group of GroupStats.
+// swagger:model
+type StatsBucket struct {
+	Key        string   `json:"key"`
+	Count      int      `json:"count"`
+	AverageAge *float64 `json:"average_age"`
+}
+
+// AgeHistogram represents the age distribution of persons matching the filters.
+// Buckets cover [from, to) ranges of BucketSize years without gaps, Unknown counts persons without age.
+// swagger:model
+type AgeHistogram struct {
+	BucketSize int         `json:"bucket_size"`
+	Total      int         `json:"total"`
+	Unknown    int         `json:"unknown"`
+	Buckets    []AgeBucket `json:"buckets"`
+}
+
+// AgeBucket represents a single range of AgeHistogram.
+// swagger:model
+type AgeBucket struct {
+	From  int `json:"from"`
+	To    int `json:"to"`
+	Count int `json:"count"`
+}
+
+// ImportReport represents the result of a bulk import: counters and an outcome for every input row.
+// Interrupted is set when the import was canceled or timed out: persons reported as created are saved,
+// rows with the "not_attempted" status were not processed and can be sent again.
+// swagger:model
+type ImportReport struct {
+	Total        int               `json:"total"`
+	Created      int               `json:"created"`
+	Failed       int               `json:"failed"`
+	NotAttempted int               `json:"not_attempted"`
+	Interrupted  bool              `json:"interrupted"`
+	Rows         []ImportRowResult `json:"rows"`
+}
+
+// ImportRowResult represents the outcome of a single imported row.
+// Row is the 1-based position of the record in the input, a CSV header is not counted.
+// Status is "created", "invalid" (the row was rejected before enrichment), "failed"
+// or "not_attempted" (the import was interrupted before the row was processed).
+// swagger:model
+type ImportRowResult struct {
+	Row    int          `json:"row"`
+	Status string       `json:"status"`
+	ID     int          `json:"id,omitempty"`
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// BulkRequest represents the request payload for bulk operations. Either IDs or Query
+// selects the persons; Query holds filters in the same format as GET /api/v1/search.
+// Query must contain at least one filter unless All is set, All without Query selects every person.
+// Set lists the fields to change and is used by bulk update only, null clears an optional field.
+// With DryRun the operation is not applied and only the affected persons are reported.
+// swagger:model
+type BulkRequest struct {
+	IDs    []int          `json:"ids,omitempty"`
+	Query  string         `json:"query,omitempty"`
+	All    bool           `json:"all,omitempty"`
+	Set    map[string]any `json:"set,omitempty"`
+	DryRun bool           `json:"dry_run"`
+}
+
+// BulkResult represents the outcome of a bulk operation: the number and IDs of affected persons.
+// swagger:model
+type BulkResult struct {
+	DryRun   bool  `json:"dry_run"`
+	Affected int   `json:"affected"`
+	IDs      []int `json:"ids"`
+}
+
+// PersonHistoryEntry represents a single change of a person record.
+// Operation is "insert", "update", "delete", "restore" or "purge". Before and After are
+// snapshots of the record around the change, Before is absent for insert and After for purge.
+// Once a person is purged, the snapshots of all its entries are erased.
+// Version is the version of the record after the change, or the last version for purge.
+// swagger:model
+type PersonHistoryEntry struct {
+	ID        int64           `json:"id"`
+	PersonID  int             `json:"person_id"`
+	Version   int             `json:"version"`
+	Operation string          `json:"operation"`
+	Actor     string          `json:"actor,omitempty"`
+	Before    json.RawMessage `json:"before,omitempty" swaggertype:"object"`
+	After     json.RawMessage `json:"after,omitempty" swaggertype:"object"`
+	ChangedAt time.Time       `json:"changed_at"`
+}
+
+// RevertRequest represents the request payload for reverting a person to a previous version.
+// swagger:model
+type RevertRequest struct {
+	Version int `json:"version" validate:"required,min=1"`
+}
+
+// AuditEntry represents a single API action recorded in the audit log.
+// TargetID is the ID from the request path or of the created resource. TargetIDs lists persons
+// affected or returned by bulk operations, imports and searches. Outcome is "success"
+// for responses with status below 400 and "failure" otherwise. PrevHash and Hash are set
+// when hash chaining is enabled: Hash covers the entry fields and the Hash of the previous chained entry.
+// swagger:model
+type AuditEntry struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Actor     string    `json:"actor,omitempty"`
+	Action    string    `json:"action"`
+	TargetID  *int      `json:"target_id,omitempty"`
+	TargetIDs []int     `json:"target_ids,omitempty"`
+	RequestID string    `json:"request_id,omitempty"`
+	ClientIP  string    `json:"client_ip,omitempty"`
+	Status    int       `json:"status"`
+	Outcome   string    `json:"outcome"`
+	PrevHash  string    `json:"prev_hash,omitempty"`
+	Hash      string    `json:"hash,omitempty"`
+}
+
+// AuditFilters represents the criteria for querying the audit log.
+type AuditFilters struct {
+	Actor    string
+	Action   string
+	TargetID *int
+	Outcome  string
+	From     time.Time
+	To       time.Time
+	Limit    int
+	Offset   int
+}
+
+// AuditPage represents a page of audit log entries, newest first.
+// swagger:model
+type AuditPage struct {
+	Entries  []AuditEntry `json:"entries"`
+	Page     int          `json:"page"`
+	PageSize int          `json:"page_size"`
+	HasNext  bool         `json:"has_next"`
+}
+
+// AuditVerification represents the result of checking the audit log hash chain.
+// BrokenID is the first entry whose hash or link to the previous entry doesn't match.
+// swagger:model
+type AuditVerification struct {
+	Valid    bool  `json:"valid"`
+	Checked  int   `json:"checked"`
+	BrokenID int64 `json:"broken_id,omitempty"`
+}
+
+// DuplicateCandidate represents an existing person that may duplicate a person being created.
+// Similarity is the trigram similarity of normalized full names from 0 to 1, Exact marks equal full names.
+// swagger:model
+type DuplicateCandidate struct {
+	Person     Person  `json:"person"`
+	Similarity float64 `json:"similarity"`
+	Exact      bool    `json:"exact"`
+}
+
+// CreatePersonResponse represents a created person. PossibleDuplicates lists similar existing
+// persons when the duplicate policy is "warn".
+// swagger:model
+type CreatePersonResponse struct {
+	Person
+	PossibleDuplicates []DuplicateCandidate `json:"possible_duplicates,omitempty"`
+}
+
+// DuplicatePair represents two existing persons that may be duplicates of each other.
+// swagger:model
+type DuplicatePair struct {
+	First      Person  `json:"first"`
+	Second     Person  `json:"second"`
+	Similarity float64 `json:"similarity"`
+	Exact      bool    `json:"exact"`
+}
+
+// DuplicatesReport represents pairs of possible duplicates among existing persons, most similar first.
+// swagger:model
+type DuplicatesReport struct {
+	Threshold float64         `json:"threshold"`
+	Pairs     []DuplicatePair `json:"pairs"`
 }
@@ -0,0 +1,35 @@
+// Package purge периодически удаляет записи, помеченные удалёнными дольше срока хранения
+package purge
+
+import (
+	"context"
+	"fmt"
+	"people-credentials-api/internal/repository"
+	"people-credentials-api/pkg/logger"
+	"time"
+)
+
+// purgeDeleted удаляет устаревшие записи, подменяется в тестах
+var purgeDeleted = repository.PurgeDeletedPeople
+
+// Run удаляет записи, помеченные удалёнными дольше retention, сразу и затем раз в interval,
+// пока не будет отменён ctx. Ошибка очистки записывается в лог и не прерывает цикл
+func Run(ctx context.Context, interval, retention time.Duration) {
+	logger.Info(fmt.Sprintf("Starting purge of persons deleted more than %s ago every %s", retention, interval))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := purgeDeleted(ctx, retention); err != nil {
+			logger.Error(fmt.Sprintf("Purge of deleted persons failed: %s", err.Error()))
+		}
+
+		select {
+		case <-ctx.Done():
+			logger.Info("Purge of deleted persons stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
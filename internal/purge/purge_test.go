@@ -0,0 +1,41 @@
+package purge
+
+import (
+	"context"
+	"errors"
+	"people-credentials-api/internal/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// -------------------------------
+// Тесты очистки удалённых записей
+// -------------------------------
+func TestRunPurgesUntilCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := make(chan time.Duration, 10)
+	purgeDeleted = func(_ context.Context, retention time.Duration) (int64, error) {
+		calls <- retention
+		if len(calls) == 3 {
+			cancel()
+		}
+		return 0, errors.New("database is unavailable")
+	}
+	defer func() { purgeDeleted = repository.PurgeDeletedPeople }()
+
+	done := make(chan struct{})
+	go func() {
+		Run(ctx, time.Millisecond, time.Hour)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not stop after context cancellation")
+	}
+	assert.Len(t, calls, 3)
+	assert.Equal(t, time.Hour, <-calls)
+}
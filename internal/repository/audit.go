@@ -0,0 +1,225 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"people-credentials-api/internal/models"
+	"people-credentials-api/pkg/logger"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// auditChainLock - ключ транзакционной advisory-блокировки, под которой к цепочке
+// хешей журнала аудита добавляется очередная запись
+const auditChainLock = 7301
+
+// auditColumns - список колонок для чтения записи журнала аудита
+const auditColumns = `id, created_at, COALESCE(actor, ''), action, target_id, target_ids, COALESCE(request_id, ''),
+	COALESCE(client_ip, ''), status, outcome, COALESCE(prev_hash, ''), COALESCE(hash, '')`
+
+// InsertAuditEntry добавляет запись в журнал аудита. При chain запись связывается
+// с последней подписанной записью: её хеш покрывает поля записи и хеш предыдущей
+func InsertAuditEntry(ctx context.Context, e models.AuditEntry, chain bool) error {
+	e.CreatedAt = e.CreatedAt.UTC().Truncate(time.Microsecond)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to begin transaction: %s", err.Error()))
+		return err
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			logger.Error(fmt.Sprintf("Failed to rollback transaction: %s", err.Error()))
+		}
+	}()
+
+	if chain {
+		if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", auditChainLock); err != nil {
+			logger.Error(fmt.Sprintf("Failed to lock audit chain: %s", err.Error()))
+			return err
+		}
+		err := tx.QueryRowContext(ctx,
+			"SELECT hash FROM audit_log WHERE hash IS NOT NULL ORDER BY id DESC LIMIT 1").Scan(&e.PrevHash)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			logger.Error(fmt.Sprintf("Failed to read last audit hash: %s", err.Error()))
+			return err
+		}
+		e.Hash = auditHash(e.PrevHash, e)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO audit_log (created_at, actor, action, target_id, target_ids, request_id, client_ip, status, outcome, prev_hash, hash)
+		VALUES ($1, NULLIF($2, ''), $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), $8, $9, NULLIF($10, ''), NULLIF($11, ''))
+	`, e.CreatedAt, e.Actor, e.Action, e.TargetID, pq.Array(e.TargetIDs), e.RequestID, e.ClientIP, e.Status, e.Outcome, e.PrevHash, e.Hash)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to insert audit entry: %s", err.Error()))
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ListAuditEntries возвращает страницу записей журнала аудита, подходящих под фильтры,
+// начиная с новых, и признак наличия следующей страницы. Действие, оканчивающееся на *,
+// выбирает все действия с этим префиксом, ID объекта ищется и среди ID массовых операций
+func ListAuditEntries(ctx context.Context, f models.AuditFilters) ([]models.AuditEntry, bool, error) {
+	var b queryBuilder
+	if f.Actor != "" {
+		b.equals("actor", f.Actor)
+	}
+	if prefix, ok := strings.CutSuffix(f.Action, "*"); ok {
+		b.where("action LIKE " + b.arg(escapeLike(prefix)+"%") + ` ESCAPE '\'`)
+	} else if f.Action != "" {
+		b.equals("action", f.Action)
+	}
+	if f.TargetID != nil {
+		id := b.arg(*f.TargetID)
+		b.where("(target_id = " + id + " OR target_ids @> ARRAY[" + id + "]::int[])")
+	}
+	if f.Outcome != "" {
+		b.equals("outcome", f.Outcome)
+	}
+	if !f.From.IsZero() {
+		b.where("created_at >= " + b.arg(f.From.UTC()))
+	}
+	if !f.To.IsZero() {
+		b.where("created_at < " + b.arg(f.To.UTC()))
+	}
+	// Лишняя запись показывает, что есть следующая страница
+	query := fmt.Sprintf("SELECT %s FROM audit_log %s ORDER BY id DESC LIMIT %s OFFSET %s",
+		auditColumns, b.whereClause(), b.arg(f.Limit+1), b.arg(f.Offset))
+
+	logger.Info(fmt.Sprintf("Executing audit query: %s | args=%v", query, b.args))
+
+	rows, err := db.QueryContext(ctx, query, b.args...)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Query failed: %s", err.Error()))
+		return nil, false, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logger.Error(fmt.Sprintf("Failed to close rows: %s", err.Error()))
+		}
+	}()
+
+	entries := []models.AuditEntry{}
+	for rows.Next() {
+		e, err := scanAuditEntry(rows)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to scan audit entry: %s", err.Error()))
+			return nil, false, err
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		logger.Error(fmt.Sprintf("Rows iteration error: %s", err.Error()))
+		return nil, false, err
+	}
+
+	hasNext := len(entries) > f.Limit
+	if hasNext {
+		entries = entries[:f.Limit]
+	}
+	return entries, hasNext, nil
+}
+
+// VerifyAuditChain проверяет цепочку хешей журнала аудита от первой подписанной записи
+// и возвращает первую запись, хеш или ссылка на предыдущую запись которой не совпадают
+func VerifyAuditChain(ctx context.Context) (models.AuditVerification, error) {
+	logger.Info("Verifying audit log hash chain")
+
+	rows, err := db.QueryContext(ctx, "SELECT "+auditColumns+" FROM audit_log WHERE hash IS NOT NULL ORDER BY id")
+	if err != nil {
+		logger.Error(fmt.Sprintf("Query failed: %s", err.Error()))
+		return models.AuditVerification{}, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logger.Error(fmt.Sprintf("Failed to close rows: %s", err.Error()))
+		}
+	}()
+
+	result := models.AuditVerification{Valid: true}
+	prev := ""
+	for rows.Next() {
+		e, err := scanAuditEntry(rows)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to scan audit entry: %s", err.Error()))
+			return models.AuditVerification{}, err
+		}
+		result.Checked++
+		if !validAuditLink(prev, e) {
+			logger.Warn(fmt.Sprintf("Audit log hash chain is broken at entry %d", e.ID))
+			result.Valid = false
+			result.BrokenID = e.ID
+			return result, nil
+		}
+		prev = e.Hash
+	}
+	if err := rows.Err(); err != nil {
+		logger.Error(fmt.Sprintf("Rows iteration error: %s", err.Error()))
+		return models.AuditVerification{}, err
+	}
+
+	logger.Info(fmt.Sprintf("Audit log hash chain is valid, %d entries checked", result.Checked))
+	return result, nil
+}
+
+func scanAuditEntry(row rowScanner) (models.AuditEntry, error) {
+	var e models.AuditEntry
+	var target sql.NullInt64
+	var targets pq.Int64Array
+	err := row.Scan(&e.ID, &e.CreatedAt, &e.Actor, &e.Action, &target, &targets, &e.RequestID,
+		&e.ClientIP, &e.Status, &e.Outcome, &e.PrevHash, &e.Hash)
+	if target.Valid {
+		id := int(target.Int64)
+		e.TargetID = &id
+	}
+	for _, id := range targets {
+		e.TargetIDs = append(e.TargetIDs, int(id))
+	}
+	return e, err
+}
+
+// validAuditLink проверяет, что запись ссылается на хеш prev предыдущей записи цепочки
+// и её собственный хеш соответствует содержимому
+func validAuditLink(prev string, e models.AuditEntry) bool {
+	return e.PrevHash == prev && e.Hash == auditHash(prev, e)
+}
+
+// auditHash вычисляет SHA-256 от хеша предыдущей записи и полей записи e
+func auditHash(prev string, e models.AuditEntry) string {
+	target := ""
+	if e.TargetID != nil {
+		target = strconv.Itoa(*e.TargetID)
+	}
+	fields := []string{
+		prev,
+		e.CreatedAt.UTC().Format(time.RFC3339Nano),
+		e.Actor,
+		e.Action,
+		target,
+		e.RequestID,
+		e.ClientIP,
+		strconv.Itoa(e.Status),
+		e.Outcome,
+	}
+	// ID массовых операций появились в журнале позже, поэтому учитываются, только если заданы:
+	// хеши записей, сделанных раньше, от этого не меняются
+	if len(e.TargetIDs) > 0 {
+		ids := make([]string, len(e.TargetIDs))
+		for i, id := range e.TargetIDs {
+			ids[i] = strconv.Itoa(id)
+		}
+		fields = append(fields, strings.Join(ids, ","))
+	}
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\n")))
+	return hex.EncodeToString(sum[:])
+}
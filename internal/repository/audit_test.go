@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"people-credentials-api/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ----------------------------------
+// Тесты цепочки хешей журнала аудита
+// ----------------------------------
+func TestAuditHashChain(t *testing.T) {
+	target := 42
+	first := models.AuditEntry{
+		CreatedAt: time.Date(2024, 5, 1, 12, 0, 0, 123456000, time.UTC),
+		Actor:     "analyst",
+		Action:    "person.get",
+		TargetID:  &target,
+		Status:    200,
+		Outcome:   "success",
+	}
+	first.Hash = auditHash("", first)
+	second := models.AuditEntry{CreatedAt: first.CreatedAt.Add(time.Second), Action: "person.search", Status: 200, Outcome: "success"}
+	second.PrevHash = first.Hash
+	second.Hash = auditHash(first.Hash, second)
+
+	assert.Len(t, first.Hash, 64)
+	assert.True(t, validAuditLink("", first))
+	assert.True(t, validAuditLink(first.Hash, second))
+
+	// Время в другом часовом поясе не меняет хеш
+	moved := first
+	moved.CreatedAt = first.CreatedAt.In(time.FixedZone("MSK", 3*60*60))
+	assert.True(t, validAuditLink("", moved))
+
+	tampered := first
+	tampered.Actor = "intruder"
+	assert.False(t, validAuditLink("", tampered))
+
+	other := 43
+	tampered = first
+	tampered.TargetID = &other
+	assert.False(t, validAuditLink("", tampered))
+
+	assert.False(t, validAuditLink("", second), "entry must reference the previous hash")
+
+	// Пустой список ID не меняет хеш записей, сделанных до его появления
+	bulk := second
+	bulk.TargetIDs = []int{}
+	assert.Equal(t, second.Hash, auditHash(first.Hash, bulk))
+
+	bulk.TargetIDs = []int{3, 5}
+	bulk.Hash = auditHash(first.Hash, bulk)
+	assert.NotEqual(t, second.Hash, bulk.Hash)
+	bulk.TargetIDs = []int{3, 6}
+	assert.False(t, validAuditLink(first.Hash, bulk))
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"people-credentials-api/internal/models"
+	"people-credentials-api/pkg/logger"
+	"sort"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// ErrBulkLimitExceeded возвращается, когда массовая операция затрагивает больше записей, чем разрешено
+var ErrBulkLimitExceeded = errors.New("bulk operation matches too many persons")
+
+// BulkDeletePeople помечает удалёнными записи, подходящие под фильтры, одной транзакцией
+// и возвращает их ID.
+// При dryRun записи только выбираются, транзакция откатывается.
+func BulkDeletePeople(ctx context.Context, filters models.Filters, maxRows int, dryRun bool) ([]int, error) {
+	return bulk(ctx, filters, maxRows, dryRun, func(tx *sql.Tx, ids []int) error {
+		query := `UPDATE people SET deleted_at = CURRENT_TIMESTAMP, version = version + 1, updated_at = CURRENT_TIMESTAMP
+			WHERE id = ANY($1)`
+		_, err := tx.ExecContext(ctx, query, pq.Array(ids))
+		return err
+	})
+}
+
+// BulkUpdatePeople изменяет поля changes у записей, подходящих под фильтры, одной транзакцией
+// и возвращает их ID. Ключи changes - JSON-имена полей, значение nil записывает NULL.
+func BulkUpdatePeople(ctx context.Context, filters models.Filters, changes map[string]any, maxRows int, dryRun bool) ([]int, error) {
+	fields := make([]string, 0, len(changes))
+	for field := range changes {
+		if _, ok := patchableColumns[field]; !ok {
+			return nil, fmt.Errorf("field %q can't be updated", field)
+		}
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	return bulk(ctx, filters, maxRows, dryRun, func(tx *sql.Tx, ids []int) error {
+		var b queryBuilder
+		sets := make([]string, 0, len(fields)+2)
+		for _, field := range fields {
+			sets = append(sets, patchableColumns[field]+" = "+b.arg(changes[field]))
+		}
+		sets = append(sets, "version = version + 1", "updated_at = CURRENT_TIMESTAMP")
+
+		query := fmt.Sprintf("UPDATE people SET %s WHERE id = ANY(%s)", strings.Join(sets, ", "), b.arg(pq.Array(ids)))
+		_, err := tx.ExecContext(ctx, query, b.args...)
+		return err
+	})
+}
+
+// bulk выбирает и блокирует записи, подходящие под фильтры, проверяет их количество
+// и применяет к ним apply в той же транзакции
+func bulk(ctx context.Context, filters models.Filters, maxRows int, dryRun bool, apply func(tx *sql.Tx, ids []int) error) ([]int, error) {
+	// Удалённые записи не изменяются массовыми операциями
+	filters.IncludeDeleted = false
+
+	var b queryBuilder
+	applyFilters(&b, filters)
+	// Лишняя запись показывает, что лимит превышен, без подсчёта всей выборки
+	query := fmt.Sprintf("SELECT id FROM people %s ORDER BY id LIMIT %s FOR UPDATE", b.whereClause(), b.arg(maxRows+1))
+
+	logger.Info(fmt.Sprintf("Executing bulk selection: %s | args=%v | dry_run=%t", query, b.args, dryRun))
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to begin transaction: %s", err.Error()))
+		return nil, err
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			logger.Error(fmt.Sprintf("Failed to rollback transaction: %s", err.Error()))
+		}
+	}()
+
+	if err := setActor(ctx, tx); err != nil {
+		logger.Error(fmt.Sprintf("Failed to set actor: %s", err.Error()))
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx, query, b.args...)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Query failed: %s", err.Error()))
+		return nil, err
+	}
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			_ = rows.Close()
+			logger.Error(fmt.Sprintf("Failed to scan row: %s", err.Error()))
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		logger.Error(fmt.Sprintf("Rows iteration error: %s", err.Error()))
+		return nil, err
+	}
+
+	if len(ids) > maxRows {
+		logger.Info(fmt.Sprintf("Bulk operation matches more than %d persons", maxRows))
+		return nil, ErrBulkLimitExceeded
+	}
+	if dryRun || len(ids) == 0 {
+		return ids, nil
+	}
+
+	if err := apply(tx, ids); err != nil {
+		logger.Error(fmt.Sprintf("Bulk operation failed: %s", err.Error()))
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		logger.Error(fmt.Sprintf("Failed to commit bulk operation: %s", err.Error()))
+		return nil, err
+	}
+
+	logger.Info(fmt.Sprintf("Bulk operation affected %d persons", len(ids)))
+	return ids, nil
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"people-credentials-api/internal/models"
+)
+
+// ErrInvalidCursor возвращается, если курсор пагинации не удаётся разобрать
+// или он был выдан для другого порядка сортировки
+var ErrInvalidCursor = errors.New("invalid pagination cursor")
+
+// EncodeCursor формирует непрозрачный курсор, указывающий на позицию сразу после записи p
+// при заданном порядке сортировки. Для сортировки по релевантности курсор не формируется
+// и возвращается пустая строка.
+func EncodeCursor(p models.Person, sort []models.SortField) string {
+	c := models.Cursor{Sort: sortSignature(sort), ID: p.ID}
+	keys := sortKeys(sort)
+	for _, k := range keys[:len(keys)-1] {
+		if k.Field == RelevanceField {
+			return ""
+		}
+		c.Values = append(c.Values, sortValue(p, k.Field))
+	}
+
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCursor разбирает курсор, полученный от клиента, и проверяет,
+// что он соответствует порядку сортировки запроса
+func DecodeCursor(token string, sort []models.SortField) (models.Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return models.Cursor{}, ErrInvalidCursor
+	}
+
+	var c models.Cursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.ID <= 0 {
+		return models.Cursor{}, ErrInvalidCursor
+	}
+	if c.Sort != sortSignature(sort) || len(c.Values) != len(sortKeys(sort))-1 {
+		return models.Cursor{}, ErrInvalidCursor
+	}
+	return c, nil
+}
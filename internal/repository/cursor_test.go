@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"people-credentials-api/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	c, err := DecodeCursor(EncodeCursor(models.Person{ID: 42}, nil), nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.Cursor{ID: 42}, c)
+}
+
+func TestCursorRoundTripWithSort(t *testing.T) {
+	sort := []models.SortField{{Field: "age", Desc: true}, {Field: "surname"}}
+
+	c, err := DecodeCursor(EncodeCursor(models.Person{ID: 7, Age: 30, Surname: "ivanov"}, sort), sort)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.Cursor{Sort: "-age,surname", Values: []string{"30", "ivanov"}, ID: 7}, c)
+}
+
+func TestDecodeCursorRejectsOtherSort(t *testing.T) {
+	token := EncodeCursor(models.Person{ID: 7, Age: 30}, []models.SortField{{Field: "age"}})
+
+	_, err := DecodeCursor(token, []models.SortField{{Field: "age", Desc: true}})
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	for _, token := range []string{"", "not base64!", "bnVsbA", "eyJpZCI6LTF9"} {
+		_, err := DecodeCursor(token, nil)
+		assert.ErrorIs(t, err, ErrInvalidCursor, token)
+	}
+}
@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"people-credentials-api/internal/models"
+	"people-credentials-api/pkg/logger"
+
+	"github.com/lib/pq"
+)
+
+// personNameLock - первый ключ advisory-блокировки, под которой проверяются дубликаты
+// и добавляется запись; второй ключ - хеш нормализованного полного имени
+const personNameLock = 7302
+
+// queryer - *sql.DB или *sql.Tx, в котором ищутся дубликаты
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// normalizedFullName возвращает выражение полного имени из выражений его частей:
+// в нижнем регистре, без пробелов по краям и с одиночными пробелами между словами
+func normalizedFullName(name, surname, patronymic string) string {
+	return fmt.Sprintf(`lower(regexp_replace(trim(%s || ' ' || %s || ' ' || %s), '\s+', ' ', 'g'))`, name, surname, patronymic)
+}
+
+// fullName возвращает выражение нормализованного полного имени записи с псевдонимом таблицы alias.
+// Выражение совпадает с индексом idx_people_full_name_trgm, иначе индекс не используется
+func fullName(alias string) string {
+	return normalizedFullName(alias+"name", alias+"surname", "COALESCE("+alias+"patronymic, '')")
+}
+
+// duplicateMatch - ID возможного дубликата и сходство полных имён
+type duplicateMatch struct {
+	firstID    int
+	secondID   int
+	similarity float64
+	exact      bool
+}
+
+// FindDuplicates ищет среди неудалённых записей возможные дубликаты человека с указанным ФИО:
+// совпадающие полные имена и имена с триграммным сходством не ниже threshold, самые похожие первыми
+func FindDuplicates(ctx context.Context, name, surname, patronymic string, threshold float64, limit int) ([]models.DuplicateCandidate, error) {
+	return findDuplicates(ctx, db, name, surname, patronymic, threshold, limit)
+}
+
+// InsertPersonWithoutDuplicates добавляет запись, только если у неё нет возможных дубликатов,
+// иначе возвращает их. Проверка и вставка выполняются в одной транзакции под блокировкой
+// по нормализованному полному имени, поэтому одновременные запросы с одинаковым именем
+// не создадут две записи. Запросы с похожими, но разными именами блокировкой не упорядочиваются
+func InsertPersonWithoutDuplicates(ctx context.Context, person models.Person, threshold float64, limit int) (models.Person, []models.DuplicateCandidate, error) {
+	logger.Info(fmt.Sprintf("Inserting person without duplicates: %+v", person))
+
+	var inserted models.Person
+	var duplicates []models.DuplicateCandidate
+	err := inActorTx(ctx, func(tx *sql.Tx) error {
+		lock := fmt.Sprintf("SELECT pg_advisory_xact_lock($1, hashtext(%s))", normalizedFullName("$2::text", "$3::text", "$4::text"))
+		if _, err := tx.ExecContext(ctx, lock, personNameLock, person.Name, person.Surname, person.Patronymic); err != nil {
+			return err
+		}
+
+		var err error
+		duplicates, err = findDuplicates(ctx, tx, person.Name, person.Surname, person.Patronymic, threshold, limit)
+		if err != nil || len(duplicates) > 0 {
+			return err
+		}
+		inserted, err = insertPerson(ctx, tx, person)
+		return err
+	})
+	if err != nil {
+		logger.Error("Failed to insert person: " + err.Error())
+		return models.Person{}, nil, err
+	}
+	if len(duplicates) > 0 {
+		logger.Info(fmt.Sprintf("Person not inserted, found %d possible duplicates", len(duplicates)))
+		return models.Person{}, duplicates, nil
+	}
+
+	logger.Info(fmt.Sprintf("Person inserted successfully with ID %d", inserted.ID))
+	return inserted, nil, nil
+}
+
+func findDuplicates(ctx context.Context, q queryer, name, surname, patronymic string, threshold float64, limit int) ([]models.DuplicateCandidate, error) {
+	// Оператор % отбирает кандидатов по индексу с порогом pg_trgm (0.3 по умолчанию),
+	// точный порог применяется к ним отдельно и поэтому не может быть ниже config.MinDuplicateThreshold
+	query := fmt.Sprintf(`
+		SELECT id, similarity(%[1]s, q.full_name) AS score, %[1]s = q.full_name
+		FROM people, (SELECT %[2]s AS full_name) q
+		WHERE deleted_at IS NULL AND %[1]s %% q.full_name AND similarity(%[1]s, q.full_name) >= $4
+		ORDER BY score DESC, id
+		LIMIT $5
+	`, fullName(""), normalizedFullName("$1::text", "$2::text", "$3::text"))
+
+	logger.Info(fmt.Sprintf("Searching duplicates of %s %s %s", name, surname, patronymic))
+
+	rows, err := q.QueryContext(ctx, query, name, surname, patronymic, threshold, limit)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Query failed: %s", err.Error()))
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logger.Error(fmt.Sprintf("Failed to close rows: %s", err.Error()))
+		}
+	}()
+
+	var matches []duplicateMatch
+	for rows.Next() {
+		var m duplicateMatch
+		if err := rows.Scan(&m.firstID, &m.similarity, &m.exact); err != nil {
+			logger.Error(fmt.Sprintf("Failed to scan row: %s", err.Error()))
+			return nil, err
+		}
+		matches = append(matches, m)
+	}
+	if err := rows.Err(); err != nil {
+		logger.Error(fmt.Sprintf("Rows iteration error: %s", err.Error()))
+		return nil, err
+	}
+
+	people, err := peopleByIDs(ctx, q, matchedIDs(matches))
+	if err != nil {
+		return nil, err
+	}
+	candidates := []models.DuplicateCandidate{}
+	for _, m := range matches {
+		candidates = append(candidates, models.DuplicateCandidate{Person: people[m.firstID], Similarity: m.similarity, Exact: m.exact})
+	}
+
+	logger.Info(fmt.Sprintf("Found %d possible duplicates", len(candidates)))
+	return candidates, nil
+}
+
+// GetDuplicatesReport возвращает до limit пар неудалённых записей с триграммным сходством
+// полных имён не ниже threshold, самые похожие первыми
+func GetDuplicatesReport(ctx context.Context, threshold float64, limit int) (models.DuplicatesReport, error) {
+	query := fmt.Sprintf(`
+		SELECT a.id, b.id, similarity(%[1]s, %[2]s) AS score, %[1]s = %[2]s
+		FROM people a
+		JOIN people b ON a.id < b.id AND %[1]s %% %[2]s
+		WHERE a.deleted_at IS NULL AND b.deleted_at IS NULL AND similarity(%[1]s, %[2]s) >= $1
+		ORDER BY score DESC, a.id, b.id
+		LIMIT $2
+	`, fullName("a."), fullName("b."))
+
+	logger.Info(fmt.Sprintf("Building duplicates report with threshold %g", threshold))
+
+	rows, err := db.QueryContext(ctx, query, threshold, limit)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Query failed: %s", err.Error()))
+		return models.DuplicatesReport{}, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logger.Error(fmt.Sprintf("Failed to close rows: %s", err.Error()))
+		}
+	}()
+
+	var matches []duplicateMatch
+	for rows.Next() {
+		var m duplicateMatch
+		if err := rows.Scan(&m.firstID, &m.secondID, &m.similarity, &m.exact); err != nil {
+			logger.Error(fmt.Sprintf("Failed to scan row: %s", err.Error()))
+			return models.DuplicatesReport{}, err
+		}
+		matches = append(matches, m)
+	}
+	if err := rows.Err(); err != nil {
+		logger.Error(fmt.Sprintf("Rows iteration error: %s", err.Error()))
+		return models.DuplicatesReport{}, err
+	}
+
+	people, err := peopleByIDs(ctx, db, matchedIDs(matches))
+	if err != nil {
+		return models.DuplicatesReport{}, err
+	}
+	report := models.DuplicatesReport{Threshold: threshold, Pairs: []models.DuplicatePair{}}
+	for _, m := range matches {
+		report.Pairs = append(report.Pairs, models.DuplicatePair{
+			First:      people[m.firstID],
+			Second:     people[m.secondID],
+			Similarity: m.similarity,
+			Exact:      m.exact,
+		})
+	}
+
+	logger.Info(fmt.Sprintf("Found %d pairs of possible duplicates", len(report.Pairs)))
+	return report, nil
+}
+
+// matchedIDs возвращает ID всех записей из найденных совпадений без повторов
+func matchedIDs(matches []duplicateMatch) []int {
+	seen := make(map[int]bool)
+	var ids []int
+	for _, m := range matches {
+		for _, id := range []int{m.firstID, m.secondID} {
+			if id != 0 && !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+// peopleByIDs читает записи с указанными ID
+func peopleByIDs(ctx context.Context, q queryer, ids []int) (map[int]models.Person, error) {
+	people := make(map[int]models.Person, len(ids))
+	if len(ids) == 0 {
+		return people, nil
+	}
+
+	rows, err := q.QueryContext(ctx, "SELECT "+personColumns+" FROM people WHERE id = ANY($1)", pq.Array(ids))
+	if err != nil {
+		logger.Error(fmt.Sprintf("Query failed: %s", err.Error()))
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logger.Error(fmt.Sprintf("Failed to close rows: %s", err.Error()))
+		}
+	}()
+
+	for rows.Next() {
+		p, err := scanPerson(rows)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to scan row: %s", err.Error()))
+			return nil, err
+		}
+		people[p.ID] = p
+	}
+	if err := rows.Err(); err != nil {
+		logger.Error(fmt.Sprintf("Rows iteration error: %s", err.Error()))
+		return nil, err
+	}
+	return people, nil
+}
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// -----------------------
+// Тесты поиска дубликатов
+// -----------------------
+func TestFullNameMatchesIndexExpression(t *testing.T) {
+	assert.Equal(t, `lower(regexp_replace(trim(name || ' ' || surname || ' ' || COALESCE(patronymic, '')), '\s+', ' ', 'g'))`, fullName(""))
+	assert.Equal(t, `lower(regexp_replace(trim(a.name || ' ' || a.surname || ' ' || COALESCE(a.patronymic, '')), '\s+', ' ', 'g'))`, fullName("a."))
+}
+
+func TestMatchedIDsSkipsRepeats(t *testing.T) {
+	ids := matchedIDs([]duplicateMatch{
+		{firstID: 3, secondID: 7},
+		{firstID: 3, secondID: 9},
+		{firstID: 7, secondID: 9},
+	})
+
+	assert.Equal(t, []int{3, 7, 9}, ids)
+	assert.Equal(t, []int{5}, matchedIDs([]duplicateMatch{{firstID: 5}}))
+	assert.Empty(t, matchedIDs(nil))
+}
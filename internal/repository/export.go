@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"people-credentials-api/internal/models"
+	"people-credentials-api/pkg/logger"
+)
+
+// exportBatchSize - количество записей, получаемых из серверного курсора за один FETCH
+const exportBatchSize = 500
+
+// StreamPeople выбирает все записи, подходящие под фильтры, через серверный курсор
+// и передаёт их в fn пачками, не загружая всю выборку в память. Пагинация фильтров игнорируется,
+// ID читается всегда, даже если не входит в запрошенные поля, чтобы выгрузку можно было записать в журнал аудита.
+// Ошибка fn прерывает выборку и возвращается вызывающему.
+func StreamPeople(ctx context.Context, filters models.Filters, fn func(batch []models.Person) error) error {
+	var b queryBuilder
+	applyFilters(&b, filters)
+
+	columns := newProjection(filters.Fields, "id")
+	query := fmt.Sprintf(`
+		DECLARE export_cursor NO SCROLL CURSOR FOR
+		SELECT %s
+		FROM people
+		%s
+		%s
+	`, columns.selectList(), b.whereClause(), orderByClause(&b, filters))
+
+	logger.Info(fmt.Sprintf("Executing StreamPeople query: %s | args=%v", query, b.args))
+
+	// Курсор существует только внутри транзакции
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to begin transaction: %s", err.Error()))
+		return err
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			logger.Error(fmt.Sprintf("Failed to rollback transaction: %s", err.Error()))
+		}
+	}()
+
+	if _, err := tx.ExecContext(ctx, query, b.args...); err != nil {
+		logger.Error(fmt.Sprintf("Failed to declare cursor: %s", err.Error()))
+		return err
+	}
+
+	total := 0
+	for {
+		batch, err := fetchBatch(ctx, tx, columns)
+		if err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		total += len(batch)
+		if err := fn(batch); err != nil {
+			return err
+		}
+	}
+
+	logger.Info(fmt.Sprintf("Streamed %d persons", total))
+	return tx.Commit()
+}
+
+// fetchBatch читает очередную пачку записей из курсора export_cursor
+func fetchBatch(ctx context.Context, tx *sql.Tx, columns projection) ([]models.Person, error) {
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf("FETCH %d FROM export_cursor", exportBatchSize))
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to fetch from cursor: %s", err.Error()))
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logger.Error(fmt.Sprintf("Failed to close rows: %s", err.Error()))
+		}
+	}()
+
+	batch := make([]models.Person, 0, exportBatchSize)
+	for rows.Next() {
+		p, err := columns.scan(rows)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to scan row: %s", err.Error()))
+			return nil, err
+		}
+		batch = append(batch, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Error(fmt.Sprintf("Rows iteration error: %s", err.Error()))
+		return nil, err
+	}
+	return batch, nil
+}
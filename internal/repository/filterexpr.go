@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"people-credentials-api/internal/filterexpr"
+	"time"
+)
+
+// FilterFields - поля, доступные в выражениях фильтра, и их типы
+var FilterFields = map[string]filterexpr.FieldType{
+	"id":          filterexpr.FieldInteger,
+	"name":        filterexpr.FieldText,
+	"surname":     filterexpr.FieldText,
+	"patronymic":  filterexpr.FieldText,
+	"age":         filterexpr.FieldInteger,
+	"gender":      filterexpr.FieldText,
+	"nationality": filterexpr.FieldText,
+	"created_at":  filterexpr.FieldTime,
+	"updated_at":  filterexpr.FieldTime,
+}
+
+// compileExpr переводит выражение фильтра в SQL-условие, значения передаются параметрами.
+// Поля берутся из sortableColumns, поэтому пустые значения сравниваются так же,
+// как они отдаются клиенту: != выбирает и записи без значения.
+func compileExpr(b *queryBuilder, node filterexpr.Node) string {
+	switch n := node.(type) {
+	case filterexpr.And:
+		return "(" + compileExpr(b, n.Left) + " AND " + compileExpr(b, n.Right) + ")"
+	case filterexpr.Or:
+		return "(" + compileExpr(b, n.Left) + " OR " + compileExpr(b, n.Right) + ")"
+	case filterexpr.Not:
+		return "NOT " + compileExpr(b, n.Expr)
+	case filterexpr.Comparison:
+		return compileComparison(b, n)
+	}
+	return "TRUE"
+}
+
+func compileComparison(b *queryBuilder, c filterexpr.Comparison) string {
+	column := sortableColumns[c.Field]
+
+	if text, ok := c.Value.(string); ok {
+		var condition string
+		if c.Prefix {
+			condition = column + " ILIKE " + b.arg(escapeLike(text)+"%") + ` ESCAPE '\'`
+		} else {
+			condition = "lower(" + column + ") = lower(" + b.arg(text) + ")"
+		}
+		if c.Op == filterexpr.OpNe {
+			return "NOT (" + condition + ")"
+		}
+		return condition
+	}
+
+	op := string(c.Op)
+	switch c.Op {
+	case filterexpr.OpEq:
+		op = "="
+	case filterexpr.OpNe:
+		op = "<>"
+	}
+	if _, ok := c.Value.(time.Time); ok {
+		// Время сравнивается с учётом смещения, как created_after и created_before
+		return column + " " + op + " " + b.arg(c.Value) + "::timestamptz"
+	}
+	return column + " " + op + " " + b.arg(c.Value)
+}
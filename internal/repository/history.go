@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"people-credentials-api/internal/models"
+	"people-credentials-api/pkg/logger"
+)
+
+// ErrHistoryVersionNotFound возвращается, когда в истории записи нет запрошенной версии
+var ErrHistoryVersionNotFound = errors.New("person version not found in history")
+
+type actorKey struct{}
+
+// WithActor сохраняет в контексте автора изменений, который попадает в историю записей
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext возвращает автора изменений из контекста или пустую строку
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
+
+// setActor передаёт автора изменений триггеру истории через настройку транзакции app.actor
+func setActor(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, "SELECT set_config('app.actor', $1, true)", ActorFromContext(ctx))
+	return err
+}
+
+// inActorTx выполняет fn в транзакции, в которой задан автор изменений для истории записей
+func inActorTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to begin transaction: %s", err.Error()))
+		return err
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			logger.Error(fmt.Sprintf("Failed to rollback transaction: %s", err.Error()))
+		}
+	}()
+
+	if err := setActor(ctx, tx); err != nil {
+		logger.Error(fmt.Sprintf("Failed to set actor: %s", err.Error()))
+		return err
+	}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetPersonHistory возвращает изменения записи в порядке их выполнения.
+// История сохраняется и после окончательного удаления записи, но уже без снимков
+func GetPersonHistory(ctx context.Context, id int) ([]models.PersonHistoryEntry, error) {
+	logger.Info(fmt.Sprintf("Fetching history of person with ID: %d", id))
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, person_id, version, operation, COALESCE(actor, ''), before, after, changed_at
+		FROM people_history
+		WHERE person_id = $1
+		ORDER BY id
+	`, id)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to fetch history of person with ID %d: %s", id, err.Error()))
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logger.Error(fmt.Sprintf("Failed to close rows: %s", err.Error()))
+		}
+	}()
+
+	entries := []models.PersonHistoryEntry{}
+	for rows.Next() {
+		var e models.PersonHistoryEntry
+		var before, after []byte
+		if err := rows.Scan(&e.ID, &e.PersonID, &e.Version, &e.Operation, &e.Actor, &before, &after, &e.ChangedAt); err != nil {
+			logger.Error(fmt.Sprintf("Failed to scan history entry: %s", err.Error()))
+			return nil, err
+		}
+		e.Before, e.After = before, after
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		logger.Error(fmt.Sprintf("Rows iteration error: %s", err.Error()))
+		return nil, err
+	}
+
+	// Записи, созданные до появления истории, не имеют в ней изменений
+	if len(entries) == 0 {
+		var exists bool
+		if err := db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM people WHERE id = $1)", id).Scan(&exists); err != nil {
+			logger.Error(fmt.Sprintf("Failed to check existence of person with ID %d: %s", id, err.Error()))
+			return nil, err
+		}
+		if !exists {
+			logger.Info(fmt.Sprintf("Person with ID %d not found", id))
+			return nil, ErrPersonNotFound
+		}
+	}
+
+	logger.Debug(fmt.Sprintf("Fetched %d history entries of person with ID %d", len(entries), id))
+	return entries, nil
+}
+
+// RevertPerson возвращает поля записи к состоянию версии version из истории, сохраняя
+// изменение как новую версию. Если expectedVersion не равен нулю, запись изменяется
+// только при совпадении текущей версии. Возвращает запись после изменения.
+func RevertPerson(ctx context.Context, id int, version int, expectedVersion int) (models.Person, error) {
+	var b queryBuilder
+	query := fmt.Sprintf(`
+		UPDATE people SET
+			name = h.after->>'name',
+			surname = h.after->>'surname',
+			patronymic = h.after->>'patronymic',
+			age = (h.after->>'age')::int,
+			gender = h.after->>'gender',
+			nationality = h.after->>'nationality',
+			version = version + 1,
+			updated_at = CURRENT_TIMESTAMP
+		FROM (
+			SELECT after FROM people_history
+			WHERE person_id = %[1]s AND (after->>'version')::int = %[2]s
+			ORDER BY id DESC
+			LIMIT 1
+		) h
+		WHERE %[3]s
+		RETURNING %[4]s
+	`, b.arg(id), b.arg(version), versionCondition(&b, id, expectedVersion), personColumns)
+
+	logger.Info(fmt.Sprintf("Reverting person with ID %d to version %d", id, version))
+
+	var p models.Person
+	err := inActorTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		p, err = scanPerson(tx.QueryRowContext(ctx, query, b.args...))
+		return err
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Person{}, missingRevertError(ctx, id, version, expectedVersion)
+	}
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to revert person with ID %d: %s", id, err.Error()))
+		return models.Person{}, err
+	}
+
+	logger.Info(fmt.Sprintf("Person with ID %d reverted to version %d", id, version))
+	return p, nil
+}
+
+// missingRevertError определяет, почему откат не затронул запись: в истории нет версии
+// (ErrHistoryVersionNotFound), записи нет или её версия изменилась
+func missingRevertError(ctx context.Context, id int, version int, expectedVersion int) error {
+	var exists bool
+	err := db.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM people_history WHERE person_id = $1 AND (after->>'version')::int = $2)
+	`, id, version).Scan(&exists)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to check history of person with ID %d: %s", id, err.Error()))
+		return err
+	}
+	if exists {
+		return missingPersonError(ctx, id, expectedVersion)
+	}
+
+	if _, err := GetPersonByID(ctx, id, false, "id"); err != nil {
+		return err
+	}
+	logger.Info(fmt.Sprintf("Person with ID %d has no version %d in history", id, version))
+	return ErrHistoryVersionNotFound
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"people-credentials-api/internal/models"
+	"people-credentials-api/pkg/logger"
+
+	"github.com/lib/pq"
+)
+
+// InsertPeople добавляет записи одной транзакцией через COPY и возвращает их ID в порядке people.
+// COPY не возвращает сгенерированные значения, поэтому ID заранее резервируются в последовательности.
+func InsertPeople(ctx context.Context, people []models.Person) ([]int, error) {
+	if len(people) == 0 {
+		return []int{}, nil
+	}
+
+	logger.Info(fmt.Sprintf("Inserting %d persons with COPY", len(people)))
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to begin transaction: %s", err.Error()))
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	if err := setActor(ctx, tx); err != nil {
+		logger.Error(fmt.Sprintf("Failed to set actor: %s", err.Error()))
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx,
+		"SELECT nextval(pg_get_serial_sequence('people', 'id')) FROM generate_series(1, $1)", len(people))
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to reserve IDs: %s", err.Error()))
+		return nil, err
+	}
+	ids := make([]int, 0, len(people))
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("people", "id", "name", "surname", "patronymic", "age", "gender", "nationality"))
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to start COPY: %s", err.Error()))
+		return nil, err
+	}
+	for i, p := range people {
+		if _, err := stmt.ExecContext(ctx, ids[i], p.Name, p.Surname,
+			nullIfZero(p.Patronymic), nullIfZero(p.Age), nullIfZero(p.Gender), nullIfZero(p.Nationality)); err != nil {
+			_ = stmt.Close()
+			logger.Error(fmt.Sprintf("Failed to copy person: %s", err.Error()))
+			return nil, err
+		}
+	}
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		_ = stmt.Close()
+		logger.Error(fmt.Sprintf("Failed to finish COPY: %s", err.Error()))
+		return nil, err
+	}
+	if err := stmt.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error(fmt.Sprintf("Failed to commit import: %s", err.Error()))
+		return nil, err
+	}
+
+	logger.Info(fmt.Sprintf("Inserted %d persons", len(ids)))
+	return ids, nil
+}
+
+// nullIfZero записывает нулевое значение как NULL, так же как NULLIF в InsertPerson
+func nullIfZero[T comparable](v T) any {
+	var zero T
+	if v == zero {
+		return nil
+	}
+	return v
+}
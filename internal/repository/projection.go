@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"people-credentials-api/internal/models"
+	"slices"
+	"strings"
+)
+
+// projectableColumn - колонка, которую можно запросить в списке полей ответа
+type projectableColumn struct {
+	field string
+	expr  string
+	dest  func(p *models.Person) any
+}
+
+// projectableColumns - поля записи и соответствующие им SQL-выражения. Необязательные поля,
+// которые могут быть NULL, приводятся к нулевым значениям
+var projectableColumns = []projectableColumn{
+	{"id", "id", func(p *models.Person) any { return &p.ID }},
+	{"name", "name", func(p *models.Person) any { return &p.Name }},
+	{"surname", "surname", func(p *models.Person) any { return &p.Surname }},
+	{"patronymic", "COALESCE(patronymic, '')", func(p *models.Person) any { return &p.Patronymic }},
+	{"age", "COALESCE(age, 0)", func(p *models.Person) any { return &p.Age }},
+	{"gender", "COALESCE(gender, '')", func(p *models.Person) any { return &p.Gender }},
+	{"nationality", "COALESCE(nationality, '')", func(p *models.Person) any { return &p.Nationality }},
+	{"version", "version", func(p *models.Person) any { return &p.Version }},
+	{"created_at", "COALESCE(created_at, 'epoch')", func(p *models.Person) any { return &p.CreatedAt }},
+	{"updated_at", "COALESCE(updated_at, 'epoch')", func(p *models.Person) any { return &p.UpdatedAt }},
+	{"deleted_at", "deleted_at", func(p *models.Person) any { return &p.DeletedAt }},
+}
+
+// allColumns - проекция со всеми полями записи
+var allColumns = projection{columns: projectableColumns}
+
+// ProjectableFields возвращает все поля записи в порядке колонок таблицы
+func ProjectableFields() []string {
+	fields := make([]string, len(projectableColumns))
+	for i, c := range projectableColumns {
+		fields[i] = c.field
+	}
+	return fields
+}
+
+// IsProjectable сообщает, можно ли запросить поле в списке полей ответа
+func IsProjectable(field string) bool {
+	return slices.ContainsFunc(projectableColumns, func(c projectableColumn) bool { return c.field == field })
+}
+
+// projection - набор колонок, читаемых из таблицы, в порядке projectableColumns
+type projection struct {
+	columns []projectableColumn
+}
+
+// newProjection выбирает колонки для запрошенных полей. Пустой список означает все поля,
+// required - поля, без которых запрос не может быть обработан (например, ключи курсора)
+func newProjection(fields []string, required ...string) projection {
+	if len(fields) == 0 {
+		return allColumns
+	}
+
+	var p projection
+	for _, c := range projectableColumns {
+		if slices.Contains(fields, c.field) || slices.Contains(required, c.field) {
+			p.columns = append(p.columns, c)
+		}
+	}
+	return p
+}
+
+func (p projection) selectList() string {
+	exprs := make([]string, len(p.columns))
+	for i, c := range p.columns {
+		exprs[i] = c.expr
+	}
+	return strings.Join(exprs, ", ")
+}
+
+func (p projection) scan(row rowScanner) (models.Person, error) {
+	var person models.Person
+	dest := make([]any, len(p.columns))
+	for i, c := range p.columns {
+		dest[i] = c.dest(&person)
+	}
+	err := row.Scan(dest...)
+	return person, err
+}
+
+// searchProjection дополняет запрошенные поля ID и полями сортировки,
+// по которым строится курсор следующей страницы
+func searchProjection(f models.Filters) projection {
+	required := []string{"id"}
+	for _, s := range f.Sort {
+		required = append(required, s.Field)
+	}
+	return newProjection(f.Fields, required...)
+}
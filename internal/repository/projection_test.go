@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"people-credentials-api/internal/models"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// --------------------
+// Тесты выбора колонок
+// --------------------
+func TestNewProjectionAllColumns(t *testing.T) {
+	assert.Equal(t, personColumns, newProjection(nil).selectList())
+	assert.Len(t, newProjection(nil).columns, 11)
+}
+
+func TestSearchProjectionAddsCursorColumns(t *testing.T) {
+	p := searchProjection(models.Filters{
+		Fields: []string{"surname", "name"},
+		Sort:   []models.SortField{{Field: "age", Desc: true}, {Field: "relevance"}},
+	})
+
+	assert.Equal(t, "id, name, surname, COALESCE(age, 0)", p.selectList())
+}
+
+type fakeRow []any
+
+func (r fakeRow) Scan(dest ...any) error {
+	for i, d := range dest {
+		reflect.ValueOf(d).Elem().Set(reflect.ValueOf(r[i]))
+	}
+	return nil
+}
+
+func TestProjectionScan(t *testing.T) {
+	p, err := newProjection([]string{"name"}, "id", "version").scan(fakeRow{7, "Ivan", 3})
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.Person{ID: 7, Name: "Ivan", Version: 3}, p)
+}
+
+func TestIsProjectable(t *testing.T) {
+	assert.True(t, IsProjectable("created_at"))
+	assert.False(t, IsProjectable("relevance"))
+	assert.False(t, IsProjectable("search_vector"))
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"people-credentials-api/internal/config"
+	"people-credentials-api/internal/models"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// queryBuilder собирает условия WHERE, вынося каждое пользовательское значение
+// в позиционный плейсхолдер ($1, $2, ...). В текст запроса попадают только
+// имена колонок и операторы, заданные в коде.
+type queryBuilder struct {
+	conditions []string
+	args       []any
+}
+
+// arg регистрирует значение как аргумент запроса и возвращает его плейсхолдер
+func (b *queryBuilder) arg(value any) string {
+	b.args = append(b.args, value)
+	return "$" + strconv.Itoa(len(b.args))
+}
+
+// where добавляет условие, которое будет объединено с остальными через AND
+func (b *queryBuilder) where(condition string) {
+	b.conditions = append(b.conditions, condition)
+}
+
+// equals добавляет условие точного совпадения колонки со значением
+func (b *queryBuilder) equals(column string, value any) {
+	b.where(column + " = " + b.arg(value))
+}
+
+// contains добавляет регистронезависимый поиск подстроки с экранированием
+// спецсимволов LIKE во входном значении
+func (b *queryBuilder) contains(column, value string) {
+	b.where(column + " ILIKE " + b.arg("%"+escapeLike(value)+"%") + ` ESCAPE '\'`)
+}
+
+// match добавляет сравнение текстовой колонки со значением в заданном режиме:
+// exact - точное совпадение, prefix - начало строки без учёта регистра,
+// contains - подстрока без учёта регистра, fuzzy - триграммное сходство (pg_trgm)
+func (b *queryBuilder) match(column, value string, mode models.MatchMode) {
+	switch mode {
+	case models.MatchExact:
+		b.equals(column, value)
+	case models.MatchPrefix:
+		b.where(column + " ILIKE " + b.arg(escapeLike(value)+"%") + ` ESCAPE '\'`)
+	case models.MatchFuzzy:
+		b.where(column + " % " + b.arg(value))
+	default:
+		b.contains(column, value)
+	}
+}
+
+// whereClause возвращает готовую секцию WHERE или пустую строку, если условий нет
+func (b *queryBuilder) whereClause() string {
+	if len(b.conditions) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(b.conditions, " AND ")
+}
+
+// fullTextConfigs - конфигурации полнотекстового поиска, по которым построен search_vector
+var fullTextConfigs = map[string]bool{"simple": true, "russian": true}
+
+// fullTextQuery добавляет плейсхолдеры конфигурации и текста tsquery и возвращает выражение запроса.
+// Каждое слово ищется как префикс, все слова должны присутствовать в записи.
+func (b *queryBuilder) fullTextQuery(q string) string {
+	cfg := config.Get().FullTextConfig
+	if !fullTextConfigs[cfg] {
+		cfg = "simple"
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i := range words {
+		words[i] += ":*"
+	}
+
+	return "to_tsquery(" + b.arg(cfg) + "::regconfig, " + b.arg(strings.Join(words, " & ")) + ")"
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike экранирует символы %, _ и \, чтобы они сравнивались буквально
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
@@ -0,0 +1,215 @@
+package repository
+
+import (
+	"people-credentials-api/internal/filterexpr"
+	"people-credentials-api/internal/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ---------------------
+// Тесты построителя SQL
+// ---------------------
+func TestApplyFiltersEmpty(t *testing.T) {
+	var b queryBuilder
+	applyFilters(&b, models.Filters{})
+
+	assert.Equal(t, "WHERE deleted_at IS NULL", b.whereClause())
+	assert.Empty(t, b.args)
+}
+
+func TestApplyFiltersIncludeDeleted(t *testing.T) {
+	var b queryBuilder
+	applyFilters(&b, models.Filters{Age: 30, IncludeDeleted: true})
+
+	assert.Equal(t, "WHERE age = $1", b.whereClause())
+	assert.Equal(t, []any{30}, b.args)
+}
+
+func TestApplyFiltersUsesPlaceholders(t *testing.T) {
+	var b queryBuilder
+	applyFilters(&b, models.Filters{ID: 7, Name: "o'neil", Age: 30, Gender: "male"})
+
+	assert.Equal(t, "WHERE id = $1 AND name ILIKE $2 ESCAPE '\\' AND age = $3 AND gender = $4 AND deleted_at IS NULL", b.whereClause())
+	assert.Equal(t, []any{7, "%o'neil%", 30, "male"}, b.args)
+}
+
+func TestApplyFiltersRangesAndSets(t *testing.T) {
+	minAge, maxAge := 18, 30
+	var b queryBuilder
+	applyFilters(&b, models.Filters{
+		AgeMin:  &minAge,
+		AgeMax:  &maxAge,
+		Genders: []string{"male", "female"},
+		IsNull:  []string{"nationality"},
+		NotNull: []string{"age"},
+	})
+
+	assert.Equal(t, "WHERE age >= $1 AND age <= $2 AND gender = ANY($3) AND nationality IS NULL AND age IS NOT NULL AND deleted_at IS NULL", b.whereClause())
+	assert.Len(t, b.args, 3)
+}
+
+func TestApplyFiltersCreatedRangeKeepsOffset(t *testing.T) {
+	after := time.Date(2024, 1, 1, 0, 0, 0, 0, time.FixedZone("", 5*60*60))
+	var b queryBuilder
+	applyFilters(&b, models.Filters{CreatedAfter: after, CreatedBefore: after.AddDate(0, 1, 0)})
+
+	assert.Equal(t, "WHERE created_at > $1::timestamptz AND created_at < $2::timestamptz AND deleted_at IS NULL", b.whereClause())
+	assert.Equal(t, []any{after, after.AddDate(0, 1, 0)}, b.args)
+}
+
+func TestApplyFiltersIDs(t *testing.T) {
+	var b queryBuilder
+	applyFilters(&b, models.Filters{IDs: []int{3, 5}})
+
+	assert.Equal(t, "WHERE id = ANY($1) AND deleted_at IS NULL", b.whereClause())
+	assert.Len(t, b.args, 1)
+}
+
+func TestApplyFiltersMatchModes(t *testing.T) {
+	var b queryBuilder
+	applyFilters(&b, models.Filters{
+		Name:            "ivan",
+		NameMatch:       models.MatchExact,
+		Surname:         "pet_",
+		SurnameMatch:    models.MatchPrefix,
+		Patronymic:      "ivanovch",
+		PatronymicMatch: models.MatchFuzzy,
+	})
+
+	assert.Equal(t, "WHERE name = $1 AND surname ILIKE $2 ESCAPE '\\' AND patronymic % $3 AND deleted_at IS NULL", b.whereClause())
+	assert.Equal(t, []any{"ivan", `pet\_%`, "ivanovch"}, b.args)
+}
+
+func TestApplyFiltersFullText(t *testing.T) {
+	var b queryBuilder
+	applyFilters(&b, models.Filters{Q: "Ivanov  Petr!"})
+
+	assert.Equal(t, "WHERE search_vector @@ to_tsquery($1::regconfig, $2) AND deleted_at IS NULL", b.whereClause())
+	assert.Equal(t, []any{"simple", "ivanov:* & petr:*"}, b.args)
+}
+
+func TestApplyFiltersExpression(t *testing.T) {
+	expr, err := filterexpr.Parse("(gender:female AND age>=30) OR NOT surname!=iv_*", FilterFields)
+	assert.NoError(t, err)
+
+	var b queryBuilder
+	applyFilters(&b, models.Filters{Name: "ivan", Expr: expr})
+
+	assert.Equal(t, "WHERE name ILIKE $1 ESCAPE '\\' AND "+
+		"((lower(COALESCE(gender, '')) = lower($2) AND COALESCE(age, 0) >= $3) OR NOT NOT (surname ILIKE $4 ESCAPE '\\')) AND deleted_at IS NULL", b.whereClause())
+	assert.Equal(t, []any{"%ivan%", "female", 30, `iv\_%`}, b.args)
+}
+
+func TestApplyFiltersExpressionTime(t *testing.T) {
+	expr, err := filterexpr.Parse("created_at>=2024-01-01T10:00:00Z", FilterFields)
+	assert.NoError(t, err)
+
+	var b queryBuilder
+	applyFilters(&b, models.Filters{Expr: expr})
+
+	assert.Equal(t, "WHERE COALESCE(created_at, 'epoch') >= $1::timestamptz AND deleted_at IS NULL", b.whereClause())
+	assert.Equal(t, []any{time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)}, b.args)
+}
+
+func TestEscapeLike(t *testing.T) {
+	assert.Equal(t, `100\%`, escapeLike("100%"))
+	assert.Equal(t, `a\_b`, escapeLike("a_b"))
+	assert.Equal(t, `c:\\dir`, escapeLike(`c:\dir`))
+	assert.Equal(t, "plain", escapeLike("plain"))
+}
+
+// FuzzApplyFilters проверяет, что пользовательский ввод никогда не попадает
+// в текст запроса: SQL для произвольных значений совпадает с SQL для безопасной
+// заглушки, а сами значения передаются только через аргументы
+func FuzzApplyFilters(f *testing.F) {
+	f.Add("ivan", "o'neil", "%_\\", "male", "RU")
+	f.Add("'; DROP TABLE people; --", "$1", "%%", "' OR '1'='1", "\x00")
+
+	f.Fuzz(func(t *testing.T, name, surname, patronymic, gender, nationality string) {
+		filters := models.Filters{
+			Name:        name,
+			Surname:     surname,
+			Patronymic:  patronymic,
+			Gender:      gender,
+			Nationality: nationality,
+		}
+		var got queryBuilder
+		applyFilters(&got, filters)
+
+		placeholder := func(s string) string {
+			if s == "" {
+				return ""
+			}
+			return "x"
+		}
+		var want queryBuilder
+		applyFilters(&want, models.Filters{
+			Name:        placeholder(name),
+			Surname:     placeholder(surname),
+			Patronymic:  placeholder(patronymic),
+			Gender:      placeholder(gender),
+			Nationality: placeholder(nationality),
+		})
+
+		if got.whereClause() != want.whereClause() {
+			t.Fatalf("query depends on user input:\n got: %s\nwant: %s", got.whereClause(), want.whereClause())
+		}
+		if len(got.args) != len(want.args) {
+			t.Fatalf("expected %d args, got %d", len(want.args), len(got.args))
+		}
+		var expected []any
+		for _, v := range []string{name, surname, patronymic} {
+			if v != "" {
+				expected = append(expected, "%"+escapeLike(v)+"%")
+			}
+		}
+		if gender != "" {
+			expected = append(expected, gender)
+		}
+		if nationality != "" {
+			expected = append(expected, "%"+escapeLike(nationality)+"%")
+		}
+		if len(expected) > 0 && !assert.Equal(t, expected, got.args) {
+			t.FailNow()
+		}
+	})
+}
+
+func FuzzEscapeLike(f *testing.F) {
+	f.Add("50%_off\\")
+	f.Add("")
+
+	f.Fuzz(func(t *testing.T, s string) {
+		escaped := escapeLike(s)
+		for i := 0; i < len(escaped); i++ {
+			switch escaped[i] {
+			case '\\':
+				i++
+				if i >= len(escaped) {
+					t.Fatalf("dangling escape in %q", escaped)
+				}
+			case '%', '_':
+				t.Fatalf("unescaped wildcard in %q", escaped)
+			}
+		}
+		if unescapeLike(escaped) != s {
+			t.Fatalf("escapeLike is not reversible: %q -> %q", s, escaped)
+		}
+	})
+}
+
+// unescapeLike выполняет обратное escapeLike преобразование
+func unescapeLike(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
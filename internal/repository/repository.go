@@ -1,17 +1,62 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"people-credentials-api/internal/config"
 	"people-credentials-api/internal/models"
 	"people-credentials-api/pkg/logger"
+	"sort"
 	"strings"
 )
 
 var db *sql.DB
 
+// ErrPersonNotFound возвращается, когда запись с указанным ID отсутствует
+var ErrPersonNotFound = errors.New("person not found")
+
+// ErrVersionConflict возвращается, когда версия записи не совпадает с ожидаемой
+var ErrVersionConflict = errors.New("person version conflict")
+
+// personColumns - список колонок для чтения записи целиком
+var personColumns = allColumns.selectList()
+
+// patchableColumns сопоставляет JSON-поля записи с колонками, доступными для частичного обновления
+var patchableColumns = map[string]string{
+	"name":        "name",
+	"surname":     "surname",
+	"patronymic":  "patronymic",
+	"age":         "age",
+	"gender":      "gender",
+	"nationality": "nationality",
+}
+
+// nullableColumns - поля, которые могут оставаться незаполненными, например если
+// внешние API не смогли сделать предсказание при обогащении
+var nullableColumns = map[string]string{
+	"patronymic":  "patronymic",
+	"age":         "age",
+	"gender":      "gender",
+	"nationality": "nationality",
+}
+
+// IsNullable сообщает, может ли поле быть незаполненным и проверяться на NULL
+func IsNullable(field string) bool {
+	_, ok := nullableColumns[field]
+	return ok
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPerson(row rowScanner) (models.Person, error) {
+	return allColumns.scan(row)
+}
+
 func Connect() {
 	logger.Info("Connecting to database")
 
@@ -21,31 +66,46 @@ func Connect() {
 		cfg.DatabaseHost, cfg.DatabasePort, cfg.DatabaseUser, cfg.DatabasePass, cfg.DatabaseName, cfg.DatabaseSSLMode,
 	)
 
+	// DSN содержит пароль, поэтому в лог попадают только адрес и имя базы
+	target := fmt.Sprintf("host=%s port=%s dbname=%s", cfg.DatabaseHost, cfg.DatabasePort, cfg.DatabaseName)
+
 	var err error
 	db, err = sql.Open("postgres", dsn)
 	if err != nil {
-		logger.Fatal(fmt.Sprintf("Failed to open database: %s %s", dsn, err.Error()))
+		logger.Fatal(fmt.Sprintf("Failed to open database: %s %s", target, err.Error()))
 	}
 
 	if err = db.Ping(); err != nil {
-		logger.Fatal(fmt.Sprintf("Failed to connect to database: %s %s", dsn, err.Error()))
+		logger.Fatal(fmt.Sprintf("Failed to connect to database: %s %s", target, err.Error()))
 	}
 
 	logger.Info("Successfully connected to the database")
 }
 
-func GetPeople(filters models.Filters) ([]models.Person, error) {
+func GetPeople(ctx context.Context, filters models.Filters) ([]models.Person, error) {
+	var b queryBuilder
+	applyFilters(&b, filters)
+
+	// При пагинации по курсору выборка продолжается после последней записи
+	// предыдущей страницы, смещение не используется
+	offset := filters.Offset
+	if filters.After != nil {
+		b.where(keysetCondition(&b, filters.Sort, *filters.After))
+		offset = 0
+	}
+
+	columns := searchProjection(filters)
 	query := fmt.Sprintf(`
-		SELECT id, name, surname, patronymic, age, gender, nationality
+		SELECT %s
 		FROM people
 		%s
-		ORDER BY id
-		LIMIT $1 OFFSET $2
-	`, getWhereClause(filters))
+		%s
+		LIMIT %s OFFSET %s
+	`, columns.selectList(), b.whereClause(), orderByClause(&b, filters), b.arg(filters.Limit), b.arg(offset))
 
-	logger.Info(fmt.Sprintf("Executing GetPeople query: %s | limit=%d, offset=%d", query, filters.Limit, filters.Offset))
+	logger.Info(fmt.Sprintf("Executing GetPeople query: %s | args=%v", query, b.args))
 
-	rows, err := db.Query(query, filters.Limit, filters.Offset)
+	rows, err := db.QueryContext(ctx, query, b.args...)
 	if err != nil {
 		logger.Error(fmt.Sprintf("Query failed: %s", err.Error()))
 		return nil, err
@@ -56,10 +116,9 @@ func GetPeople(filters models.Filters) ([]models.Person, error) {
 		}
 	}()
 
-	var people []models.Person
+	people := []models.Person{}
 	for rows.Next() {
-		var p models.Person
-		err := rows.Scan(&p.ID, &p.Name, &p.Surname, &p.Patronymic, &p.Age, &p.Gender, &p.Nationality)
+		p, err := columns.scan(rows)
 		if err != nil {
 			logger.Error(fmt.Sprintf("Failed to scan row: %s", err.Error()))
 			return nil, err
@@ -77,106 +136,310 @@ func GetPeople(filters models.Filters) ([]models.Person, error) {
 	return people, nil
 }
 
-func InsertPerson(person models.Person) error {
-	query := `
-		INSERT INTO people (name, surname, patronymic, age, gender, nationality)
-		VALUES ($1, $2, $3, $4, $5, $6)
-	`
+// CountPeople возвращает общее число записей, удовлетворяющих фильтрам, без учёта пагинации
+func CountPeople(ctx context.Context, filters models.Filters) (int, error) {
+	var b queryBuilder
+	applyFilters(&b, filters)
+
+	query := "SELECT COUNT(*) FROM people " + b.whereClause()
+
+	logger.Info(fmt.Sprintf("Executing CountPeople query: %s | args=%v", query, b.args))
+
+	var total int
+	if err := db.QueryRowContext(ctx, query, b.args...).Scan(&total); err != nil {
+		logger.Error(fmt.Sprintf("Count query failed: %s", err.Error()))
+		return 0, err
+	}
+	return total, nil
+}
+
+// GetPersonByID читает запись по ID. Удалённая запись возвращается только при includeDeleted.
+// Если переданы fields, читаются только эти поля, а также ID и версия, нужная для ETag
+func GetPersonByID(ctx context.Context, id int, includeDeleted bool, fields ...string) (models.Person, error) {
+	columns := newProjection(fields, "id", "version")
+	query := `SELECT ` + columns.selectList() + ` FROM people WHERE id = $1`
+	if !includeDeleted {
+		query += ` AND deleted_at IS NULL`
+	}
 
+	logger.Info(fmt.Sprintf("Fetching person with ID: %d", id))
+
+	p, err := columns.scan(db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Info(fmt.Sprintf("Person with ID %d not found", id))
+		return models.Person{}, ErrPersonNotFound
+	}
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to fetch person with ID %d: %s", id, err.Error()))
+		return models.Person{}, err
+	}
+
+	logger.Debug(fmt.Sprintf("Fetched person: %+v", p))
+	return p, nil
+}
+
+func InsertPerson(ctx context.Context, person models.Person) (models.Person, error) {
 	logger.Info(fmt.Sprintf("Inserting person: %+v", person))
 
-	_, err := db.Exec(query,
+	var inserted models.Person
+	err := inActorTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		inserted, err = insertPerson(ctx, tx, person)
+		return err
+	})
+
+	if err != nil {
+		logger.Error("Failed to insert person: " + err.Error())
+		return models.Person{}, err
+	}
+
+	logger.Info(fmt.Sprintf("Person inserted successfully with ID %d", inserted.ID))
+	return inserted, nil
+}
+
+// insertPerson добавляет запись в транзакции tx и возвращает её вместе со сгенерированными полями
+func insertPerson(ctx context.Context, tx *sql.Tx, person models.Person) (models.Person, error) {
+	query := `
+		INSERT INTO people (name, surname, patronymic, age, gender, nationality)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, 0), NULLIF($5, ''), NULLIF($6, ''))
+		RETURNING ` + personColumns
+
+	return scanPerson(tx.QueryRowContext(ctx, query,
 		person.Name,
 		person.Surname,
 		person.Patronymic,
 		person.Age,
 		person.Gender,
 		person.Nationality,
-	)
-
-	if err != nil {
-		logger.Error("Failed to insert person: " + err.Error())
-		return err
-	}
-
-	logger.Info("Person inserted successfully")
-	return nil
+	))
 }
 
-func DeletePersonByID(id int) error {
+// DeletePersonByID помечает запись удалённой: она пропадает из поиска, но может быть
+// восстановлена до окончательного удаления по истечении срока хранения.
+// Если expectedVersion не равен нулю, запись удаляется только при совпадении версии,
+// иначе возвращается ErrVersionConflict.
+func DeletePersonByID(ctx context.Context, id int, expectedVersion int) error {
 	logger.Info(fmt.Sprintf("Deleting person with ID: %d", id))
 
-	_, err := db.Exec("DELETE FROM people WHERE id = $1", id)
+	var b queryBuilder
+	b.where(versionCondition(&b, id, expectedVersion))
+
+	query := "UPDATE people SET deleted_at = CURRENT_TIMESTAMP, version = version + 1, updated_at = CURRENT_TIMESTAMP " +
+		b.whereClause()
+	var n int64
+	err := inActorTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, query, b.args...)
+		if err != nil {
+			return err
+		}
+		n, err = res.RowsAffected()
+		return err
+	})
 	if err != nil {
 		logger.Error(fmt.Sprintf("Failed to delete person with ID %d: %s", id, err.Error()))
 		return err
 	}
+	if n == 0 {
+		return missingPersonError(ctx, id, expectedVersion)
+	}
 
-	logger.Info(fmt.Sprintf("Person with ID %d deleted successfully", id))
+	logger.Info(fmt.Sprintf("Person with ID %d marked as deleted", id))
 	return nil
 }
 
-func UpdatePerson(id int, updated models.Person) error {
-	query := `
+// UpdatePerson перезаписывает все поля записи и возвращает её новое состояние.
+// Пустые необязательные поля записываются как NULL, так же как в InsertPerson.
+// Если expectedVersion не равен нулю, запись обновляется только при совпадении версии.
+func UpdatePerson(ctx context.Context, id int, updated models.Person, expectedVersion int) (models.Person, error) {
+	var b queryBuilder
+	query := fmt.Sprintf(`
 		UPDATE people SET
-			name = $1,
-			surname = $2,
-			patronymic = $3,
-			age = $4,
-			gender = $5,
-			nationality = $6,
+			name = %s,
+			surname = %s,
+			patronymic = NULLIF(%s, ''),
+			age = NULLIF(%s, 0),
+			gender = NULLIF(%s, ''),
+			nationality = NULLIF(%s, ''),
+			version = version + 1,
 			updated_at = CURRENT_TIMESTAMP
-		WHERE id = $7
-	`
+		WHERE %s
+		RETURNING %s
+	`,
+		b.arg(updated.Name),
+		b.arg(updated.Surname),
+		b.arg(updated.Patronymic),
+		b.arg(updated.Age),
+		b.arg(updated.Gender),
+		b.arg(updated.Nationality),
+		versionCondition(&b, id, expectedVersion),
+		personColumns,
+	)
 
 	logger.Info(fmt.Sprintf("Updating person with ID %d to: %+v", id, updated))
 
-	_, err := db.Exec(query,
-		updated.Name,
-		updated.Surname,
-		updated.Patronymic,
-		updated.Age,
-		updated.Gender,
-		updated.Nationality,
-		id,
-	)
-
+	var p models.Person
+	err := inActorTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		p, err = scanPerson(tx.QueryRowContext(ctx, query, b.args...))
+		return err
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Person{}, missingPersonError(ctx, id, expectedVersion)
+	}
 	if err != nil {
 		logger.Error(fmt.Sprintf("Failed to update person with ID %d: %s", id, err.Error()))
-		return err
+		return models.Person{}, err
 	}
 
 	logger.Info(fmt.Sprintf("Person with ID %d updated successfully", id))
-	return nil
+	return p, nil
+}
+
+// PatchPerson обновляет только переданные поля записи. Ключи changes - JSON-имена полей,
+// значение nil записывает NULL. Если expectedVersion не равен нулю, запись обновляется
+// только при совпадении версии. Возвращает запись после обновления.
+func PatchPerson(ctx context.Context, id int, changes map[string]any, expectedVersion int) (models.Person, error) {
+	if len(changes) == 0 {
+		p, err := GetPersonByID(ctx, id, false)
+		if err == nil && expectedVersion != 0 && p.Version != expectedVersion {
+			return models.Person{}, ErrVersionConflict
+		}
+		return p, err
+	}
+
+	fields := make([]string, 0, len(changes))
+	for field := range changes {
+		if _, ok := patchableColumns[field]; !ok {
+			return models.Person{}, fmt.Errorf("field %q can't be patched", field)
+		}
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	var b queryBuilder
+	sets := make([]string, 0, len(fields)+2)
+	for _, field := range fields {
+		sets = append(sets, patchableColumns[field]+" = "+b.arg(changes[field]))
+	}
+	sets = append(sets, "version = version + 1", "updated_at = CURRENT_TIMESTAMP")
+
+	query := fmt.Sprintf(`
+		UPDATE people SET %s
+		WHERE %s
+		RETURNING %s
+	`, strings.Join(sets, ", "), versionCondition(&b, id, expectedVersion), personColumns)
+
+	logger.Info(fmt.Sprintf("Patching person with ID %d: %v", id, changes))
+
+	var p models.Person
+	err := inActorTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		p, err = scanPerson(tx.QueryRowContext(ctx, query, b.args...))
+		return err
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Person{}, missingPersonError(ctx, id, expectedVersion)
+	}
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to patch person with ID %d: %s", id, err.Error()))
+		return models.Person{}, err
+	}
+
+	logger.Info(fmt.Sprintf("Person with ID %d patched successfully", id))
+	return p, nil
+}
+
+// versionCondition возвращает условие выбора неудалённой записи по ID и, если задана, по ожидаемой версии
+func versionCondition(b *queryBuilder, id int, expectedVersion int) string {
+	condition := "id = " + b.arg(id) + " AND deleted_at IS NULL"
+	if expectedVersion != 0 {
+		condition += " AND version = " + b.arg(expectedVersion)
+	}
+	return condition
 }
 
-func getWhereClause(f models.Filters) string {
-	conditions := []string{}
+// missingPersonError определяет, почему запрос не затронул запись:
+// записи нет (ErrPersonNotFound) или её версия изменилась (ErrVersionConflict)
+func missingPersonError(ctx context.Context, id int, expectedVersion int) error {
+	if expectedVersion == 0 {
+		logger.Info(fmt.Sprintf("Person with ID %d not found", id))
+		return ErrPersonNotFound
+	}
 
+	var exists bool
+	err := db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM people WHERE id = $1 AND deleted_at IS NULL)", id).Scan(&exists)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to check existence of person with ID %d: %s", id, err.Error()))
+		return err
+	}
+	if !exists {
+		logger.Info(fmt.Sprintf("Person with ID %d not found", id))
+		return ErrPersonNotFound
+	}
+
+	logger.Info(fmt.Sprintf("Person with ID %d has version other than %d", id, expectedVersion))
+	return ErrVersionConflict
+}
+
+// applyFilters переносит критерии поиска в условия построителя запроса
+func applyFilters(b *queryBuilder, f models.Filters) {
 	if f.ID != 0 {
-		conditions = append(conditions, fmt.Sprintf("id = %d", f.ID))
+		b.equals("id", f.ID)
+	}
+	if len(f.IDs) > 0 {
+		b.where("id = ANY(" + b.arg(pq.Array(f.IDs)) + ")")
+	}
+	if f.Q != "" {
+		b.where("search_vector @@ " + b.fullTextQuery(f.Q))
 	}
 	if f.Name != "" {
-		conditions = append(conditions, fmt.Sprintf("name ILIKE '%%%s%%'", strings.ReplaceAll(f.Name, "'", "''")))
+		b.match("name", f.Name, f.NameMatch)
 	}
 	if f.Surname != "" {
-		conditions = append(conditions, fmt.Sprintf("surname ILIKE '%%%s%%'", strings.ReplaceAll(f.Surname, "'", "''")))
+		b.match("surname", f.Surname, f.SurnameMatch)
 	}
 	if f.Patronymic != "" {
-		conditions = append(conditions, fmt.Sprintf("patronymic ILIKE '%%%s%%'", strings.ReplaceAll(f.Patronymic, "'", "''")))
+		b.match("patronymic", f.Patronymic, f.PatronymicMatch)
 	}
 	if f.Age != 0 {
-		conditions = append(conditions, fmt.Sprintf("age = %d", f.Age))
+		b.equals("age", f.Age)
 	}
 	if f.Gender != "" {
-		conditions = append(conditions, fmt.Sprintf("gender = '%s'", strings.ReplaceAll(f.Gender, "'", "''")))
+		b.equals("gender", f.Gender)
 	}
 	if f.Nationality != "" {
-		conditions = append(conditions, fmt.Sprintf("nationality ILIKE '%%%s%%'", strings.ReplaceAll(f.Nationality, "'", "''")))
+		b.contains("nationality", f.Nationality)
 	}
-
-	if len(conditions) > 0 {
-		return "WHERE " + strings.Join(conditions, " AND ")
+	if f.AgeMin != nil {
+		b.where("age >= " + b.arg(*f.AgeMin))
+	}
+	if f.AgeMax != nil {
+		b.where("age <= " + b.arg(*f.AgeMax))
+	}
+	if len(f.Genders) > 0 {
+		b.where("gender = ANY(" + b.arg(pq.Array(f.Genders)) + ")")
+	}
+	if len(f.Nationalities) > 0 {
+		b.where("nationality = ANY(" + b.arg(pq.Array(f.Nationalities)) + ")")
+	}
+	// created_at хранится без часового пояса по времени сессии БД. Приведение к timestamptz
+	// сохраняет смещение параметра, и Postgres сравнивает моменты времени, а не показания часов
+	if !f.CreatedAfter.IsZero() {
+		b.where("created_at > " + b.arg(f.CreatedAfter) + "::timestamptz")
+	}
+	if !f.CreatedBefore.IsZero() {
+		b.where("created_at < " + b.arg(f.CreatedBefore) + "::timestamptz")
+	}
+	for _, field := range f.IsNull {
+		b.where(nullableColumns[field] + " IS NULL")
+	}
+	for _, field := range f.NotNull {
+		b.where(nullableColumns[field] + " IS NOT NULL")
+	}
+	if f.Expr != nil {
+		b.where(compileExpr(b, f.Expr))
+	}
+	if !f.IncludeDeleted {
+		b.where("deleted_at IS NULL")
 	}
-	return ""
 }
@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"people-credentials-api/internal/filterexpr"
+	"people-credentials-api/internal/models"
+	"people-credentials-api/pkg/logger"
+)
+
+// ErrSavedSearchNotFound возвращается, когда сохранённого поиска с указанным ID не существует
+var ErrSavedSearchNotFound = errors.New("saved search not found")
+
+// ErrNotSavedSearchOwner возвращается при попытке изменить или удалить чужой сохранённый поиск
+var ErrNotSavedSearchOwner = errors.New("saved search belongs to another owner")
+
+const savedSearchColumns = `id, name, owner, query, filters, COALESCE(created_at, 'epoch'), COALESCE(updated_at, 'epoch')`
+
+// scanSavedSearch читает сохранённый поиск и восстанавливает разобранное выражение фильтра.
+// Фильтры, которые больше не разбираются (например, после удаления поля из FilterFields), не считаются
+// ошибкой чтения: поиск возвращается с причиной в поле Invalid, чтобы одна запись не ломала весь список
+func scanSavedSearch(row rowScanner) (models.SavedSearch, error) {
+	var s models.SavedSearch
+	var filters []byte
+	if err := row.Scan(&s.ID, &s.Name, &s.Owner, &s.Query, &filters, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		return models.SavedSearch{}, err
+	}
+	if err := json.Unmarshal(filters, &s.Filters); err != nil {
+		logger.Error(fmt.Sprintf("Saved search %d has malformed filters: %s", s.ID, err.Error()))
+		s.Filters = models.Filters{}
+		s.Invalid = "malformed filters"
+		return s, nil
+	}
+	if s.Filters.Filter != "" {
+		expr, err := filterexpr.Parse(s.Filters.Filter, FilterFields)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Saved search %d has invalid filter expression: %s", s.ID, err.Error()))
+			s.Invalid = "invalid filter expression: " + err.Error()
+			return s, nil
+		}
+		s.Filters.Expr = expr
+	}
+	return s, nil
+}
+
+// ListSavedSearches возвращает страницу сохранённых поисков, упорядоченных по ID,
+// и признак наличия следующей страницы. Если owner не пуст, возвращаются только поиски этого владельца
+func ListSavedSearches(ctx context.Context, owner string, limit, offset int) ([]models.SavedSearch, bool, error) {
+	var b queryBuilder
+	if owner != "" {
+		b.equals("owner", owner)
+	}
+	// Лишняя запись показывает, что есть следующая страница
+	query := `SELECT ` + savedSearchColumns + ` FROM saved_searches ` + b.whereClause() +
+		` ORDER BY id LIMIT ` + b.arg(limit+1) + ` OFFSET ` + b.arg(offset)
+
+	logger.Info(fmt.Sprintf("Executing ListSavedSearches query: %s | args=%v", query, b.args))
+
+	rows, err := db.QueryContext(ctx, query, b.args...)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Query failed: %s", err.Error()))
+		return nil, false, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logger.Error(fmt.Sprintf("Failed to close rows: %s", err.Error()))
+		}
+	}()
+
+	searches := []models.SavedSearch{}
+	for rows.Next() {
+		s, err := scanSavedSearch(rows)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to scan row: %s", err.Error()))
+			return nil, false, err
+		}
+		searches = append(searches, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Error(fmt.Sprintf("Rows iteration error: %s", err.Error()))
+		return nil, false, err
+	}
+
+	hasNext := len(searches) > limit
+	if hasNext {
+		searches = searches[:limit]
+	}
+	return searches, hasNext, nil
+}
+
+func GetSavedSearch(ctx context.Context, id int) (models.SavedSearch, error) {
+	query := `SELECT ` + savedSearchColumns + ` FROM saved_searches WHERE id = $1`
+
+	logger.Info(fmt.Sprintf("Fetching saved search with ID: %d", id))
+
+	s, err := scanSavedSearch(db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Info(fmt.Sprintf("Saved search with ID %d not found", id))
+		return models.SavedSearch{}, ErrSavedSearchNotFound
+	}
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to fetch saved search with ID %d: %s", id, err.Error()))
+		return models.SavedSearch{}, err
+	}
+	return s, nil
+}
+
+func InsertSavedSearch(ctx context.Context, s models.SavedSearch) (models.SavedSearch, error) {
+	filters, err := json.Marshal(s.Filters)
+	if err != nil {
+		return models.SavedSearch{}, err
+	}
+
+	query := `
+		INSERT INTO saved_searches (name, owner, query, filters)
+		VALUES ($1, $2, $3, $4)
+		RETURNING ` + savedSearchColumns
+
+	logger.Info(fmt.Sprintf("Inserting saved search %q for %s", s.Name, s.Owner))
+
+	inserted, err := scanSavedSearch(db.QueryRowContext(ctx, query, s.Name, s.Owner, s.Query, filters))
+	if err != nil {
+		logger.Error("Failed to insert saved search: " + err.Error())
+		return models.SavedSearch{}, err
+	}
+
+	logger.Info(fmt.Sprintf("Saved search inserted successfully with ID %d", inserted.ID))
+	return inserted, nil
+}
+
+// UpdateSavedSearch перезаписывает название и параметры сохранённого поиска.
+// Изменить поиск может только его владелец, иначе возвращается ErrNotSavedSearchOwner
+func UpdateSavedSearch(ctx context.Context, id int, owner string, s models.SavedSearch) (models.SavedSearch, error) {
+	filters, err := json.Marshal(s.Filters)
+	if err != nil {
+		return models.SavedSearch{}, err
+	}
+
+	query := `
+		UPDATE saved_searches SET
+			name = $1,
+			query = $2,
+			filters = $3,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $4 AND owner = $5
+		RETURNING ` + savedSearchColumns
+
+	logger.Info(fmt.Sprintf("Updating saved search with ID %d", id))
+
+	updated, err := scanSavedSearch(db.QueryRowContext(ctx, query, s.Name, s.Query, filters, id, owner))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.SavedSearch{}, missingSavedSearchError(ctx, id)
+	}
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to update saved search with ID %d: %s", id, err.Error()))
+		return models.SavedSearch{}, err
+	}
+
+	logger.Info(fmt.Sprintf("Saved search with ID %d updated successfully", id))
+	return updated, nil
+}
+
+// DeleteSavedSearch удаляет сохранённый поиск. Удалить поиск может только его владелец
+func DeleteSavedSearch(ctx context.Context, id int, owner string) error {
+	logger.Info(fmt.Sprintf("Deleting saved search with ID: %d", id))
+
+	res, err := db.ExecContext(ctx, "DELETE FROM saved_searches WHERE id = $1 AND owner = $2", id, owner)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to delete saved search with ID %d: %s", id, err.Error()))
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to get affected rows for saved search with ID %d: %s", id, err.Error()))
+		return err
+	}
+	if n == 0 {
+		return missingSavedSearchError(ctx, id)
+	}
+
+	logger.Info(fmt.Sprintf("Saved search with ID %d deleted successfully", id))
+	return nil
+}
+
+// missingSavedSearchError определяет, почему запрос не затронул сохранённый поиск:
+// его нет (ErrSavedSearchNotFound) или он принадлежит другому владельцу (ErrNotSavedSearchOwner)
+func missingSavedSearchError(ctx context.Context, id int) error {
+	var exists bool
+	err := db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM saved_searches WHERE id = $1)", id).Scan(&exists)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to check existence of saved search with ID %d: %s", id, err.Error()))
+		return err
+	}
+	if !exists {
+		logger.Info(fmt.Sprintf("Saved search with ID %d not found", id))
+		return ErrSavedSearchNotFound
+	}
+
+	logger.Info(fmt.Sprintf("Saved search with ID %d belongs to another owner", id))
+	return ErrNotSavedSearchOwner
+}
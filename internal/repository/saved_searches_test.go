@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"encoding/json"
+	"people-credentials-api/internal/filterexpr"
+	"people-credentials-api/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// --------------------------------
+// Тесты чтения сохранённых поисков
+// --------------------------------
+func TestScanSavedSearchRestoresFilters(t *testing.T) {
+	minAge := 30
+	filters, err := json.Marshal(models.Filters{
+		Gender: "female",
+		AgeMin: &minAge,
+		Filter: "nationality:KZ",
+		Sort:   []models.SortField{{Field: "age", Desc: true}},
+		Limit:  20,
+		Offset: 40,
+	})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"gender":"female","age_min":30,"filter":"nationality:KZ","sort":[{"field":"age","desc":true}]}`, string(filters))
+
+	now := time.Now()
+	s, err := scanSavedSearch(fakeRow{3, "Women over 30", "analyst", "age_min=30", filters, now, now})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "female", s.Filters.Gender)
+	assert.Equal(t, &minAge, s.Filters.AgeMin)
+	assert.Equal(t, filterexpr.Comparison{Field: "nationality", Op: filterexpr.OpEq, Value: "KZ"}, s.Filters.Expr)
+	assert.Zero(t, s.Filters.Limit)
+}
+
+func TestScanSavedSearchFlagsInvalidExpression(t *testing.T) {
+	now := time.Now()
+	s, err := scanSavedSearch(fakeRow{3, "Broken", "analyst", "", []byte(`{"filter":"salary>1"}`), now, now})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, s.ID)
+	assert.Contains(t, s.Invalid, "invalid filter expression")
+	assert.Nil(t, s.Filters.Expr)
+}
+
+func TestScanSavedSearchFlagsMalformedFilters(t *testing.T) {
+	now := time.Now()
+	s, err := scanSavedSearch(fakeRow{3, "Broken", "analyst", "", []byte(`{"age_min":"thirty"}`), now, now})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "malformed filters", s.Invalid)
+	assert.Equal(t, models.Filters{}, s.Filters)
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"people-credentials-api/internal/models"
+	"people-credentials-api/pkg/logger"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ErrPersonNotDeleted возвращается при попытке восстановить запись, которая не была удалена
+var ErrPersonNotDeleted = errors.New("person is not deleted")
+
+// RestorePerson снимает с записи отметку об удалении и возвращает её новое состояние
+func RestorePerson(ctx context.Context, id int) (models.Person, error) {
+	logger.Info(fmt.Sprintf("Restoring person with ID: %d", id))
+
+	query := `
+		UPDATE people SET deleted_at = NULL, version = version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND deleted_at IS NOT NULL
+		RETURNING ` + personColumns
+
+	var p models.Person
+	err := inActorTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		p, err = scanPerson(tx.QueryRowContext(ctx, query, id))
+		return err
+	})
+	if err == nil {
+		logger.Info(fmt.Sprintf("Person with ID %d restored successfully", id))
+		return p, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		logger.Error(fmt.Sprintf("Failed to restore person with ID %d: %s", id, err.Error()))
+		return models.Person{}, err
+	}
+
+	var exists bool
+	if err := db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM people WHERE id = $1)", id).Scan(&exists); err != nil {
+		logger.Error(fmt.Sprintf("Failed to check existence of person with ID %d: %s", id, err.Error()))
+		return models.Person{}, err
+	}
+	if !exists {
+		logger.Info(fmt.Sprintf("Person with ID %d not found", id))
+		return models.Person{}, ErrPersonNotFound
+	}
+
+	logger.Info(fmt.Sprintf("Person with ID %d is not deleted", id))
+	return models.Person{}, ErrPersonNotDeleted
+}
+
+// PurgeDeletedPeople окончательно удаляет записи, помеченные удалёнными раньше,
+// чем retention назад, и возвращает их количество. Граница считается в БД: deleted_at
+// хранится без часового пояса по часам сессии БД, и время приложения с ним несравнимо.
+// В той же транзакции из истории удалённых записей стираются снимки: история показывает,
+// кто и когда менял запись, но персональных данных в ней не остаётся
+func PurgeDeletedPeople(ctx context.Context, retention time.Duration) (int64, error) {
+	var ids []int64
+	err := inActorTx(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, `
+			DELETE FROM people
+			WHERE deleted_at IS NOT NULL AND deleted_at < CURRENT_TIMESTAMP - make_interval(secs => $1)
+			RETURNING id
+		`, retention.Seconds())
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				_ = rows.Close()
+				return err
+			}
+			ids = append(ids, id)
+		}
+		if err := rows.Close(); err != nil {
+			return err
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		// Запись об окончательном удалении добавляется триггером, поэтому стирается тоже здесь
+		_, err = tx.ExecContext(ctx,
+			"UPDATE people_history SET before = NULL, after = NULL WHERE person_id = ANY($1)", pq.Array(ids))
+		return err
+	})
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to purge deleted persons: %s", err.Error()))
+		return 0, err
+	}
+
+	logger.Info(fmt.Sprintf("Purged %d persons deleted more than %s ago", len(ids), retention))
+	return int64(len(ids)), nil
+}
@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"people-credentials-api/internal/models"
+	"strconv"
+	"strings"
+)
+
+// timestampLayout - формат значений TIMESTAMP в курсорах, совместимый с Postgres
+const timestampLayout = "2006-01-02 15:04:05.999999"
+
+// sortableColumns - белый список полей для сортировки и соответствующие им SQL-выражения.
+// NULL приводится к тем же значениям, что и при чтении записи, чтобы значения
+// в курсоре совпадали с отсортированными.
+var sortableColumns = map[string]string{
+	"id":          "id",
+	"name":        "name",
+	"surname":     "surname",
+	"patronymic":  "COALESCE(patronymic, '')",
+	"age":         "COALESCE(age, 0)",
+	"gender":      "COALESCE(gender, '')",
+	"nationality": "COALESCE(nationality, '')",
+	"created_at":  "COALESCE(created_at, 'epoch')",
+	"updated_at":  "COALESCE(updated_at, 'epoch')",
+	"relevance":   "",
+}
+
+// RelevanceField - псевдополе сортировки по степени совпадения с полнотекстовым
+// запросом и нечёткими текстовыми фильтрами. Его выражение зависит от фильтров запроса, поэтому
+// пагинация по курсору для него недоступна.
+const RelevanceField = "relevance"
+
+// IsSortable сообщает, разрешена ли сортировка по полю
+func IsSortable(field string) bool {
+	_, ok := sortableColumns[field]
+	return ok
+}
+
+// sortKeys возвращает ключи сортировки, дополненные ID для однозначного порядка.
+// Ключи после id не влияют на порядок и отбрасываются.
+func sortKeys(sort []models.SortField) []models.SortField {
+	keys := make([]models.SortField, 0, len(sort)+1)
+	for _, s := range sort {
+		keys = append(keys, s)
+		if s.Field == "id" {
+			return keys
+		}
+	}
+	return append(keys, models.SortField{Field: "id"})
+}
+
+// orderByClause формирует секцию ORDER BY по ключам сортировки
+func orderByClause(b *queryBuilder, f models.Filters) string {
+	parts := []string{}
+	for _, k := range sortKeys(f.Sort) {
+		part := sortableColumns[k.Field]
+		if k.Field == RelevanceField {
+			part = relevanceExpr(b, f)
+		}
+		if k.Desc {
+			part += " DESC"
+		}
+		parts = append(parts, part)
+	}
+	return "ORDER BY " + strings.Join(parts, ", ")
+}
+
+// relevanceExpr возвращает выражение степени совпадения записи с запросом:
+// наибольшее из ранга полнотекстового поиска и триграммного сходства полей с режимом fuzzy
+func relevanceExpr(b *queryBuilder, f models.Filters) string {
+	scores := []string{}
+	if f.Q != "" {
+		scores = append(scores, "ts_rank(search_vector, "+b.fullTextQuery(f.Q)+")")
+	}
+	for _, t := range []struct {
+		column string
+		value  string
+		mode   models.MatchMode
+	}{
+		{"name", f.Name, f.NameMatch},
+		{"surname", f.Surname, f.SurnameMatch},
+		{"patronymic", f.Patronymic, f.PatronymicMatch},
+	} {
+		if t.value != "" && t.mode == models.MatchFuzzy {
+			scores = append(scores, "similarity("+t.column+", "+b.arg(t.value)+")")
+		}
+	}
+
+	switch len(scores) {
+	case 0:
+		return "0"
+	case 1:
+		return scores[0]
+	}
+	return "GREATEST(" + strings.Join(scores, ", ") + ")"
+}
+
+// keysetCondition формирует условие выборки записей, следующих за курсором
+// при заданном порядке сортировки:
+// (k1 > v1) OR (k1 = v1 AND k2 > v2) OR ... с "<" для убывающих ключей
+func keysetCondition(b *queryBuilder, sort []models.SortField, c models.Cursor) string {
+	keys := sortKeys(sort)
+	values := make([]any, 0, len(keys))
+	for _, v := range c.Values {
+		values = append(values, v)
+	}
+	values = append(values, c.ID)
+
+	alternatives := []string{}
+	for i, k := range keys {
+		terms := []string{}
+		for j := 0; j < i; j++ {
+			terms = append(terms, sortableColumns[keys[j].Field]+" = "+b.arg(values[j]))
+		}
+		op := " > "
+		if k.Desc {
+			op = " < "
+		}
+		terms = append(terms, sortableColumns[k.Field]+op+b.arg(values[i]))
+		alternatives = append(alternatives, "("+strings.Join(terms, " AND ")+")")
+	}
+	return "(" + strings.Join(alternatives, " OR ") + ")"
+}
+
+// sortValue возвращает значение ключа сортировки записи в виде строки для курсора
+func sortValue(p models.Person, field string) string {
+	switch field {
+	case "name":
+		return p.Name
+	case "surname":
+		return p.Surname
+	case "patronymic":
+		return p.Patronymic
+	case "age":
+		return strconv.Itoa(p.Age)
+	case "gender":
+		return p.Gender
+	case "nationality":
+		return p.Nationality
+	case "created_at":
+		return p.CreatedAt.Format(timestampLayout)
+	case "updated_at":
+		return p.UpdatedAt.Format(timestampLayout)
+	}
+	return strconv.Itoa(p.ID)
+}
+
+// sortSignature кодирует порядок сортировки, чтобы курсор нельзя было
+// применить к выборке с другим порядком
+func sortSignature(sort []models.SortField) string {
+	parts := []string{}
+	for _, s := range sort {
+		if s.Desc {
+			parts = append(parts, "-"+s.Field)
+		} else {
+			parts = append(parts, s.Field)
+		}
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"people-credentials-api/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderByClauseAddsIDTieBreaker(t *testing.T) {
+	var b queryBuilder
+	assert.Equal(t, "ORDER BY id", orderByClause(&b, models.Filters{}))
+	assert.Equal(t, "ORDER BY COALESCE(age, 0) DESC, surname, id",
+		orderByClause(&b, models.Filters{Sort: []models.SortField{{Field: "age", Desc: true}, {Field: "surname"}}}))
+	assert.Equal(t, "ORDER BY id DESC",
+		orderByClause(&b, models.Filters{Sort: []models.SortField{{Field: "id", Desc: true}, {Field: "name"}}}))
+}
+
+func TestOrderByRelevance(t *testing.T) {
+	var b queryBuilder
+	f := models.Filters{
+		Name:         "ivan",
+		NameMatch:    models.MatchFuzzy,
+		Surname:      "petrov",
+		SurnameMatch: models.MatchFuzzy,
+		Sort:         []models.SortField{{Field: RelevanceField, Desc: true}},
+	}
+
+	assert.Equal(t, "ORDER BY GREATEST(similarity(name, $1), similarity(surname, $2)) DESC, id", orderByClause(&b, f))
+	assert.Equal(t, []any{"ivan", "petrov"}, b.args)
+}
+
+func TestKeysetCondition(t *testing.T) {
+	var b queryBuilder
+	sort := []models.SortField{{Field: "age", Desc: true}, {Field: "surname"}}
+
+	cond := keysetCondition(&b, sort, models.Cursor{Values: []string{"30", "ivanov"}, ID: 7})
+
+	assert.Equal(t, "((COALESCE(age, 0) < $1) OR "+
+		"(COALESCE(age, 0) = $2 AND surname > $3) OR "+
+		"(COALESCE(age, 0) = $4 AND surname = $5 AND id > $6))", cond)
+	assert.Equal(t, []any{"30", "30", "ivanov", "30", "ivanov", 7}, b.args)
+}
@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"people-credentials-api/internal/models"
+	"people-credentials-api/pkg/logger"
+)
+
+// UnknownGroupKey - ключ группы записей, у которых поле группировки не заполнено
+const UnknownGroupKey = "unknown"
+
+// groupableColumns - поля, по которым можно группировать статистику
+var groupableColumns = map[string]string{
+	"gender":      "gender",
+	"nationality": "nationality",
+}
+
+// IsGroupable сообщает, можно ли группировать статистику по полю
+func IsGroupable(field string) bool {
+	_, ok := groupableColumns[field]
+	return ok
+}
+
+// GetStatsSummary считает количество записей и агрегаты возраста по записям, подходящим под фильтры
+func GetStatsSummary(ctx context.Context, filters models.Filters) (models.StatsSummary, error) {
+	var b queryBuilder
+	applyFilters(&b, filters)
+
+	query := "SELECT COUNT(*), COUNT(age), AVG(age)::float8, MIN(age), MAX(age) FROM people " + b.whereClause()
+
+	logger.Info(fmt.Sprintf("Executing GetStatsSummary query: %s | args=%v", query, b.args))
+
+	var s models.StatsSummary
+	var avg sql.NullFloat64
+	var minAge, maxAge sql.NullInt64
+	if err := db.QueryRowContext(ctx, query, b.args...).Scan(&s.Total, &s.WithAge, &avg, &minAge, &maxAge); err != nil {
+		logger.Error(fmt.Sprintf("Stats query failed: %s", err.Error()))
+		return models.StatsSummary{}, err
+	}
+	s.AverageAge = nullFloat(avg)
+	s.MinAge = nullInt(minAge)
+	s.MaxAge = nullInt(maxAge)
+	return s, nil
+}
+
+// GetGroupStats считает записи в группах по значению поля, от самых больших групп к меньшим.
+// Если limit больше нуля, возвращается не больше limit групп, но Total учитывает все записи
+func GetGroupStats(ctx context.Context, field string, filters models.Filters, limit int) (models.GroupStats, error) {
+	column, ok := groupableColumns[field]
+	if !ok {
+		return models.GroupStats{}, fmt.Errorf("can't group by %q", field)
+	}
+
+	var b queryBuilder
+	applyFilters(&b, filters)
+	key := fmt.Sprintf("COALESCE(%s, %s)", column, b.arg(UnknownGroupKey))
+
+	query := fmt.Sprintf(`
+		SELECT %s, COUNT(*), AVG(age)::float8, (SUM(COUNT(*)) OVER ())::bigint
+		FROM people
+		%s
+		GROUP BY 1
+		ORDER BY 2 DESC, 1
+	`, key, b.whereClause())
+	if limit > 0 {
+		query += " LIMIT " + b.arg(limit)
+	}
+
+	logger.Info(fmt.Sprintf("Executing GetGroupStats query: %s | args=%v", query, b.args))
+
+	rows, err := db.QueryContext(ctx, query, b.args...)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Stats query failed: %s", err.Error()))
+		return models.GroupStats{}, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logger.Error(fmt.Sprintf("Failed to close rows: %s", err.Error()))
+		}
+	}()
+
+	stats := models.GroupStats{GroupBy: field, Buckets: []models.StatsBucket{}}
+	for rows.Next() {
+		var bucket models.StatsBucket
+		var avg sql.NullFloat64
+		if err := rows.Scan(&bucket.Key, &bucket.Count, &avg, &stats.Total); err != nil {
+			logger.Error(fmt.Sprintf("Failed to scan row: %s", err.Error()))
+			return models.GroupStats{}, err
+		}
+		bucket.AverageAge = nullFloat(avg)
+		stats.Buckets = append(stats.Buckets, bucket)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Error(fmt.Sprintf("Rows iteration error: %s", err.Error()))
+		return models.GroupStats{}, err
+	}
+	return stats, nil
+}
+
+// GetAgeHistogram распределяет записи по возрастным интервалам длиной bucketSize лет.
+// Интервалы без записей между минимальным и максимальным возрастом возвращаются с нулевым количеством
+func GetAgeHistogram(ctx context.Context, filters models.Filters, bucketSize int) (models.AgeHistogram, error) {
+	var b queryBuilder
+	applyFilters(&b, filters)
+	size := b.arg(bucketSize)
+
+	query := fmt.Sprintf(`
+		SELECT age / %s * %s, COUNT(*)
+		FROM people
+		%s
+		GROUP BY 1
+		ORDER BY 1 NULLS LAST
+	`, size, size, b.whereClause())
+
+	logger.Info(fmt.Sprintf("Executing GetAgeHistogram query: %s | args=%v", query, b.args))
+
+	rows, err := db.QueryContext(ctx, query, b.args...)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Stats query failed: %s", err.Error()))
+		return models.AgeHistogram{}, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logger.Error(fmt.Sprintf("Failed to close rows: %s", err.Error()))
+		}
+	}()
+
+	counts := map[int]int{}
+	unknown := 0
+	for rows.Next() {
+		var from sql.NullInt64
+		var count int
+		if err := rows.Scan(&from, &count); err != nil {
+			logger.Error(fmt.Sprintf("Failed to scan row: %s", err.Error()))
+			return models.AgeHistogram{}, err
+		}
+		if !from.Valid {
+			unknown = count
+			continue
+		}
+		counts[int(from.Int64)] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Error(fmt.Sprintf("Rows iteration error: %s", err.Error()))
+		return models.AgeHistogram{}, err
+	}
+	return ageHistogram(counts, unknown, bucketSize), nil
+}
+
+// ageHistogram строит непрерывную гистограмму по количествам записей в непустых интервалах
+func ageHistogram(counts map[int]int, unknown, bucketSize int) models.AgeHistogram {
+	h := models.AgeHistogram{BucketSize: bucketSize, Total: unknown, Unknown: unknown, Buckets: []models.AgeBucket{}}
+	if len(counts) == 0 {
+		return h
+	}
+
+	lowest, highest := -1, -1
+	for from := range counts {
+		if lowest == -1 || from < lowest {
+			lowest = from
+		}
+		highest = max(highest, from)
+	}
+
+	for from := lowest; from <= highest; from += bucketSize {
+		h.Buckets = append(h.Buckets, models.AgeBucket{From: from, To: from + bucketSize, Count: counts[from]})
+		h.Total += counts[from]
+	}
+	return h
+}
+
+func nullFloat(v sql.NullFloat64) *float64 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Float64
+}
+
+func nullInt(v sql.NullInt64) *int {
+	if !v.Valid {
+		return nil
+	}
+	n := int(v.Int64)
+	return &n
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"people-credentials-api/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ----------------
+// Тесты статистики
+// ----------------
+func TestAgeHistogramFillsGaps(t *testing.T) {
+	h := ageHistogram(map[int]int{20: 3, 50: 1}, 2, 10)
+
+	assert.Equal(t, models.AgeHistogram{
+		BucketSize: 10,
+		Total:      6,
+		Unknown:    2,
+		Buckets: []models.AgeBucket{
+			{From: 20, To: 30, Count: 3},
+			{From: 30, To: 40, Count: 0},
+			{From: 40, To: 50, Count: 0},
+			{From: 50, To: 60, Count: 1},
+		},
+	}, h)
+}
+
+func TestAgeHistogramEmpty(t *testing.T) {
+	h := ageHistogram(map[int]int{}, 0, 5)
+
+	assert.Equal(t, models.AgeHistogram{BucketSize: 5, Buckets: []models.AgeBucket{}}, h)
+}
+
+func TestIsGroupable(t *testing.T) {
+	assert.True(t, IsGroupable("gender"))
+	assert.True(t, IsGroupable("nationality"))
+	assert.False(t, IsGroupable("name"))
+}
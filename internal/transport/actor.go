@@ -0,0 +1,45 @@
+package transport
+
+import (
+	"net/http"
+	"people-credentials-api/internal/repository"
+	"strings"
+)
+
+// actorHeader - заголовок с идентификатором пользователя, от имени которого выполняется запрос
+const actorHeader = "X-Actor"
+
+// actorFromRequest возвращает идентификатор пользователя из заголовка X-Actor.
+// Допускаются латинские буквы, цифры и символы - _ . @ длиной до 128 символов
+func actorFromRequest(r *http.Request) (string, error) {
+	actor := strings.TrimSpace(r.Header.Get(actorHeader))
+	if actor == "" {
+		return "", badRequest(CodeActorRequired, "X-Actor header is required")
+	}
+	if len(actor) > 128 {
+		return "", badRequest(CodeActorRequired, "X-Actor header must be at most 128 characters long")
+	}
+	for _, c := range actor {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("-_.@", c)) {
+			return "", badRequest(CodeActorRequired, "X-Actor header contains invalid characters")
+		}
+	}
+	return actor, nil
+}
+
+// withActor передаёт автора изменений из заголовка X-Actor в контекст запроса для истории записей.
+// Заголовок необязателен, но если передан, должен быть корректным
+func withActor(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(actorHeader) == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		actor, err := actorFromRequest(r)
+		if err != nil {
+			ErrorResponse(w, r, err)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(repository.WithActor(r.Context(), actor)))
+	})
+}
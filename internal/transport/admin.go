@@ -0,0 +1,37 @@
+package transport
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"people-credentials-api/internal/config"
+	"people-credentials-api/internal/models"
+)
+
+// adminKeyHeader - заголовок с ключом администратора
+const adminKeyHeader = "X-Admin-Key"
+
+// isAdmin проверяет ключ администратора из заголовка X-Admin-Key.
+// Если ключ в конфигурации не задан, административные операции недоступны
+func isAdmin(r *http.Request) bool {
+	key := config.Get().AdminAPIKey
+	if key == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(r.Header.Get(adminKeyHeader)), []byte(key)) == 1
+}
+
+// requireAdmin возвращает ошибку 403, если запрос выполняется не администратором
+func requireAdmin(r *http.Request) error {
+	if !isAdmin(r) {
+		return &requestError{status: http.StatusForbidden, code: CodeForbidden, detail: "Operation requires a valid X-Admin-Key header"}
+	}
+	return nil
+}
+
+// authorizeFilters проверяет, что удалённые записи запрашивает администратор
+func authorizeFilters(r *http.Request, f models.Filters) error {
+	if f.IncludeDeleted {
+		return requireAdmin(r)
+	}
+	return nil
+}
@@ -0,0 +1,267 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"path"
+	"people-credentials-api/internal/config"
+	"people-credentials-api/internal/models"
+	"people-credentials-api/internal/repository"
+	"people-credentials-api/pkg/logger"
+	"strconv"
+	"time"
+)
+
+// Результаты действий в журнале аудита
+const (
+	auditSuccess = "success"
+	auditFailure = "failure"
+)
+
+// insertAuditEntry сохраняет запись журнала аудита, подменяется в тестах
+var insertAuditEntry = repository.InsertAuditEntry
+
+// auditRecord - сведения о действии, которые обработчик маршрута передаёт журналу аудита
+type auditRecord struct {
+	action    string
+	targetID  *int
+	targetIDs []int
+}
+
+// withAudit записывает в журнал аудита каждый запрос к маршрутам, помеченным audited:
+// автора, действие, ID объекта, идентификатор запроса, IP клиента и результат.
+// Запросы к неизвестным маршрутам не записываются
+func withAudit(chain bool, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		record := &auditRecord{}
+		sw := &statusWriter{ResponseWriter: w}
+
+		// Прерванная паникой обработка, например оборванная выгрузка, записывается как неудачная
+		completed := false
+		defer func() {
+			if record.action == "" {
+				return
+			}
+			entry := models.AuditEntry{
+				CreatedAt: time.Now(),
+				Actor:     repository.ActorFromContext(r.Context()),
+				Action:    record.action,
+				TargetID:  record.targetID,
+				TargetIDs: record.targetIDs,
+				RequestID: requestIDFromContext(r.Context()),
+				ClientIP:  clientIP(r),
+				Status:    sw.statusCode(),
+				Outcome:   auditSuccess,
+			}
+			if entry.TargetID == nil && entry.Status == http.StatusCreated {
+				entry.TargetID = idFromLocation(sw.Header().Get("Location"))
+			}
+			if !completed || entry.Status >= http.StatusBadRequest {
+				entry.Outcome = auditFailure
+			}
+			if err := insertAuditEntry(context.WithoutCancel(r.Context()), entry, chain); err != nil {
+				logger.Error(fmt.Sprintf("Failed to record audit entry for %s (%s): %s", entry.Action, entry.RequestID, err.Error()))
+			}
+		}()
+
+		next.ServeHTTP(sw, r.WithContext(context.WithValue(r.Context(), auditRecordKey, record)))
+		completed = true
+	})
+}
+
+// audited помечает обработчик маршрута действием журнала аудита. ID объекта берётся
+// из параметра пути id после обработки, когда его уже перенесли устаревшие маршруты
+func audited(action string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		record, ok := r.Context().Value(auditRecordKey).(*auditRecord)
+		if !ok {
+			next(w, r)
+			return
+		}
+		record.action = action
+		defer func() {
+			if id, err := strconv.Atoi(r.PathValue("id")); err == nil {
+				record.targetID = &id
+			}
+		}()
+		next(w, r)
+	}
+}
+
+// auditTargets передаёт журналу аудита ID записей, которые обработчик изменил или вернул клиенту
+// помимо объекта из пути: массовые операции, импорт, поиск, выгрузка и найденные дубликаты
+func auditTargets(r *http.Request, ids []int) {
+	if record, ok := r.Context().Value(auditRecordKey).(*auditRecord); ok {
+		record.targetIDs = append(record.targetIDs, ids...)
+	}
+}
+
+// statusWriter запоминает код ответа для журнала аудита
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush нужен потоковой выгрузке, которая сбрасывает ответ по частям
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		f.Flush()
+	}
+}
+
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *statusWriter) statusCode() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// clientIP возвращает адрес клиента без порта
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// idFromLocation возвращает ID созданного объекта из последнего сегмента заголовка Location
+func idFromLocation(location string) *int {
+	if location == "" {
+		return nil
+	}
+	id, err := strconv.Atoi(path.Base(location))
+	if err != nil {
+		return nil
+	}
+	return &id
+}
+
+// ListAuditHandler godoc
+// @Summary Query the Audit Log
+// @Description Lists recorded API actions, newest first. Requires administrator key.
+// @Tags audit
+// @Produce json
+// @Produce application/problem+json
+// @Param X-Admin-Key header string true "Administrator key"
+// @Param actor query string false "Filter by X-Actor of the request"
+// @Param action query string false "Filter by action, e.g. person.update; a trailing * matches a prefix, e.g. person.*"
+// @Param target_id query int false "Filter by ID of the person or saved search, including persons affected by bulk operations, imports and searches"
+// @Param outcome query string false "Filter by outcome: success or failure"
+// @Param from query string false "Recorded at or after the moment (RFC 3339 or YYYY-MM-DD)"
+// @Param to query string false "Recorded before the moment (RFC 3339 or YYYY-MM-DD)"
+// @Param page query int false "Page number for pagination"
+// @Param page_size query int false "Number of entries per page (default 20, limited by server maximum)"
+// @Success 200 {object} models.AuditPage "Audit log entries"
+// @Failure 400 {object} models.Problem "Bad Request"
+// @Failure 403 {object} models.Problem "Forbidden"
+// @Failure 500 {object} models.Problem "Internal Server Error"
+// @Router /api/v1/audit [get]
+func ListAuditHandler(w http.ResponseWriter, r *http.Request) {
+	if err := requireAdmin(r); err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+	filters, err := parseAuditFilters(r)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	entries, hasNext, err := repository.ListAuditEntries(r.Context(), filters)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, models.AuditPage{
+		Entries:  entries,
+		Page:     filters.Offset/filters.Limit + 1,
+		PageSize: filters.Limit,
+		HasNext:  hasNext,
+	})
+}
+
+// VerifyAuditHandler godoc
+// @Summary Verify the Audit Log
+// @Description Checks the hash chain of the audit log and reports the first tampered entry.
+// @Description Only entries recorded with hash chaining enabled are checked. Requires administrator key.
+// @Tags audit
+// @Produce json
+// @Produce application/problem+json
+// @Param X-Admin-Key header string true "Administrator key"
+// @Success 200 {object} models.AuditVerification "Verification result"
+// @Failure 403 {object} models.Problem "Forbidden"
+// @Failure 500 {object} models.Problem "Internal Server Error"
+// @Router /api/v1/audit/verify [get]
+func VerifyAuditHandler(w http.ResponseWriter, r *http.Request) {
+	if err := requireAdmin(r); err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	result, err := repository.VerifyAuditChain(r.Context())
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, result)
+}
+
+// parseAuditFilters разбирает параметры запроса журнала аудита
+func parseAuditFilters(r *http.Request) (models.AuditFilters, error) {
+	q := r.URL.Query()
+	f := models.AuditFilters{
+		Actor:   q.Get("actor"),
+		Action:  q.Get("action"),
+		Outcome: q.Get("outcome"),
+	}
+
+	var err error
+	if f.Outcome != "" && f.Outcome != auditSuccess && f.Outcome != auditFailure {
+		return f, badRequest(CodeInvalidQuery, "outcome must be success or failure")
+	}
+	if f.TargetID, err = parseOptionalInt(q, "target_id"); err != nil {
+		return f, err
+	}
+	if f.From, err = parseTime(q, "from"); err != nil {
+		return f, err
+	}
+	if f.To, err = parseTime(q, "to"); err != nil {
+		return f, err
+	}
+
+	page, err := parseIntParam(q, "page", 1, 1, 1<<20)
+	if err != nil {
+		return f, err
+	}
+	if f.Limit, err = parseIntParam(q, "page_size", defaultPageSize, 1, config.Get().MaxPageSize); err != nil {
+		return f, err
+	}
+	f.Offset = (page - 1) * f.Limit
+	return f, nil
+}
@@ -0,0 +1,132 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"people-credentials-api/internal/models"
+	"people-credentials-api/internal/repository"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// --------------------
+// Тесты журнала аудита
+// --------------------
+
+// recordAudit подменяет запись журнала аудита и возвращает сохранённые записи
+func recordAudit(t *testing.T) *[]models.AuditEntry {
+	entries := &[]models.AuditEntry{}
+	insertAuditEntry = func(_ context.Context, e models.AuditEntry, chain bool) error {
+		assert.True(t, chain)
+		*entries = append(*entries, e)
+		return nil
+	}
+	t.Cleanup(func() { insertAuditEntry = repository.InsertAuditEntry })
+	return entries
+}
+
+func TestAuditRecordsActionTargetAndOutcome(t *testing.T) {
+	entries := recordAudit(t)
+	handler := withRequestID(withActor(withAudit(true, newRouter())))
+
+	r := httptest.NewRequest(http.MethodPatch, "/api/v1/persons/42", strings.NewReader(`{}`))
+	r.Header.Set("If-Match", "not-an-etag")
+	r.Header.Set(actorHeader, "analyst")
+	r.Header.Set("X-Request-ID", "req-1")
+	r.RemoteAddr = "10.0.0.7:51234"
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	assert.Len(t, *entries, 1)
+	e := (*entries)[0]
+	assert.Equal(t, "person.patch", e.Action)
+	assert.Equal(t, 42, *e.TargetID)
+	assert.Equal(t, "analyst", e.Actor)
+	assert.Equal(t, "req-1", e.RequestID)
+	assert.Equal(t, "10.0.0.7", e.ClientIP)
+	assert.Equal(t, http.StatusBadRequest, e.Status)
+	assert.Equal(t, auditFailure, e.Outcome)
+}
+
+func TestAuditTargetOfLegacyRouteAndCreatedResource(t *testing.T) {
+	entries := recordAudit(t)
+	router := http.NewServeMux()
+	router.HandleFunc("POST /items", audited("item.create", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", "/items/17")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	router.HandleFunc("DELETE /legacy", audited("item.delete", idFromQuery(func(w http.ResponseWriter, r *http.Request) {})))
+	handler := withAudit(true, router)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/items", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/legacy?id=5", nil))
+
+	assert.Len(t, *entries, 2)
+	assert.Equal(t, 17, *(*entries)[0].TargetID)
+	assert.Equal(t, auditSuccess, (*entries)[0].Outcome)
+	assert.Equal(t, 5, *(*entries)[1].TargetID)
+	assert.Equal(t, http.StatusOK, (*entries)[1].Status)
+}
+
+func TestAuditRecordsAffectedTargets(t *testing.T) {
+	entries := recordAudit(t)
+	router := http.NewServeMux()
+	router.HandleFunc("POST /items/bulk", audited("item.bulk_delete", func(w http.ResponseWriter, r *http.Request) {
+		auditTargets(r, []int{3, 5})
+		auditTargets(r, []int{8})
+	}))
+	withAudit(true, router).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/items/bulk", nil))
+
+	assert.Len(t, *entries, 1)
+	assert.Nil(t, (*entries)[0].TargetID)
+	assert.Equal(t, []int{3, 5, 8}, (*entries)[0].TargetIDs)
+
+	// Без журнала аудита ID просто не сохраняются
+	auditTargets(httptest.NewRequest(http.MethodPost, "/items/bulk", nil), []int{1})
+}
+
+func TestAuditSkipsUnknownRoutesAndRecordsPanics(t *testing.T) {
+	entries := recordAudit(t)
+	router := http.NewServeMux()
+	router.HandleFunc("GET /stream", audited("item.export", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("partial"))
+		panic(http.ErrAbortHandler)
+	}))
+	handler := withAudit(true, router)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/unknown", nil))
+	assert.Empty(t, *entries)
+
+	assert.Panics(t, func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/stream", nil))
+	})
+	assert.Len(t, *entries, 1)
+	assert.Equal(t, http.StatusOK, (*entries)[0].Status)
+	assert.Equal(t, auditFailure, (*entries)[0].Outcome)
+}
+
+func TestAuditListRequiresAdmin(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newRouter().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/audit?actor=analyst", nil))
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestParseAuditFilters(t *testing.T) {
+	f, err := parseAuditFilters(httptest.NewRequest(http.MethodGet,
+		"/api/v1/audit?action=person.*&target_id=42&outcome=failure&from=2024-05-01&page=3&page_size=10", nil))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "person.*", f.Action)
+	assert.Equal(t, 42, *f.TargetID)
+	assert.Equal(t, 2024, f.From.Year())
+	assert.Equal(t, 10, f.Limit)
+	assert.Equal(t, 20, f.Offset)
+
+	for _, query := range []string{"outcome=ok", "target_id=abc", "to=yesterday", "page=0"} {
+		_, err := parseAuditFilters(httptest.NewRequest(http.MethodGet, "/api/v1/audit?"+query, nil))
+		assert.Equal(t, CodeInvalidQuery, problemFromError(err).Code, query)
+	}
+}
@@ -0,0 +1,204 @@
+package transport
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"people-credentials-api/internal/config"
+	"people-credentials-api/internal/models"
+	"people-credentials-api/internal/repository"
+	"people-credentials-api/internal/validation"
+	"slices"
+)
+
+// BulkDeleteHandler godoc
+// @Summary Delete Persons in Bulk
+// @Description Marks persons selected by a list of IDs or by search parameters as deleted in a single transaction.
+// @Description Unknown or invalid query parameters are rejected; a query without filters requires all=true.
+// @Description Already deleted persons are never selected.
+// @Description With dry_run the persons are not deleted and only the affected IDs are returned.
+// @Tags person
+// @Accept json
+// @Produce json
+// @Produce application/problem+json
+// @Param payload body models.BulkRequest true "Bulk Delete Request"
+// @Success 200 {object} models.BulkResult "Affected persons"
+// @Failure 400 {object} models.Problem "Bad Request"
+// @Failure 422 {object} models.Problem "Too Many Persons Selected"
+// @Failure 500 {object} models.Problem "Internal Server Error"
+// @Router /api/v1/persons/bulk-delete [post]
+func BulkDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	payload, filters, err := bulkRequest(r)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+	if len(payload.Set) > 0 {
+		ErrorResponse(w, r, badRequest(CodeInvalidBody, "set is not allowed in bulk delete"))
+		return
+	}
+
+	ids, err := repository.BulkDeletePeople(r.Context(), filters, config.Get().BulkMaxRows, payload.DryRun)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	auditTargets(r, ids)
+	jsonResponse(w, http.StatusOK, models.BulkResult{DryRun: payload.DryRun, Affected: len(ids), IDs: ids})
+}
+
+// BulkUpdateHandler godoc
+// @Summary Update Persons in Bulk
+// @Description Sets the given fields of persons selected by a list of IDs or by search parameters in a single transaction.
+// @Description Unknown or invalid query parameters are rejected; a query without filters requires all=true.
+// @Description With dry_run the persons are not updated and only the affected IDs are returned.
+// @Tags person
+// @Accept json
+// @Produce json
+// @Produce application/problem+json
+// @Param payload body models.BulkRequest true "Bulk Update Request"
+// @Success 200 {object} models.BulkResult "Affected persons"
+// @Failure 400 {object} models.Problem "Bad Request"
+// @Failure 422 {object} models.Problem "Validation Failed or Too Many Persons Selected"
+// @Failure 500 {object} models.Problem "Internal Server Error"
+// @Router /api/v1/persons/bulk-update [post]
+func BulkUpdateHandler(w http.ResponseWriter, r *http.Request) {
+	payload, filters, err := bulkRequest(r)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+	changes, err := bulkChanges(payload.Set)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	ids, err := repository.BulkUpdatePeople(r.Context(), filters, changes, config.Get().BulkMaxRows, payload.DryRun)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	auditTargets(r, ids)
+	jsonResponse(w, http.StatusOK, models.BulkResult{DryRun: payload.DryRun, Affected: len(ids), IDs: ids})
+}
+
+// bulkFilterKeys - параметры поиска, которые сужают выбор записей массовой операции
+var bulkFilterKeys = []string{
+	"id", "q", "name", "surname", "patronymic", "age", "gender", "nationality",
+	"age_min", "age_max", "created_after", "created_before", "is_null", "not_null", "filter",
+}
+
+// bulkMatchKeys - режимы сравнения, которые уточняют текстовые фильтры, но сами записи не выбирают
+var bulkMatchKeys = []string{"name_match", "surname_match", "patronymic_match"}
+
+// bulkRequest читает тело массовой операции и строит фильтры выбора записей:
+// по списку ID или по параметрам поиска, но не по тому и другому сразу
+func bulkRequest(r *http.Request) (models.BulkRequest, models.Filters, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return models.BulkRequest{}, models.Filters{}, badRequest(CodeInvalidBody, "Can't read request body")
+	}
+	defer r.Body.Close()
+
+	var payload models.BulkRequest
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return models.BulkRequest{}, models.Filters{}, badRequest(CodeInvalidBody, "Can't parse request body")
+	}
+
+	switch {
+	case len(payload.IDs) > 0 && (payload.Query != "" || payload.All):
+		return payload, models.Filters{}, badRequest(CodeInvalidBody, "ids can't be used together with query or all")
+	case len(payload.IDs) > 0:
+		if maxRows := config.Get().BulkMaxRows; len(payload.IDs) > maxRows {
+			return payload, models.Filters{}, repository.ErrBulkLimitExceeded
+		}
+		if slices.ContainsFunc(payload.IDs, func(id int) bool { return id <= 0 }) {
+			return payload, models.Filters{}, badRequest(CodeInvalidBody, "ids must be positive integers")
+		}
+		return payload, models.Filters{IDs: payload.IDs}, nil
+	case payload.Query != "" || payload.All:
+		filters, err := bulkFilters(payload.Query, payload.All)
+		return payload, filters, err
+	}
+	return payload, models.Filters{}, badRequest(CodeInvalidBody, "either ids or query must be provided")
+}
+
+// bulkFilters разбирает параметры поиска массовой операции строже, чем GET /api/v1/search:
+// ошибка в запросе не должна расширить выбор до всех записей, поэтому неизвестные, повторяющиеся,
+// пустые и неразбираемые параметры отклоняются, а запрос без фильтров допускается только с all
+func bulkFilters(query string, all bool) (models.Filters, error) {
+	q, err := url.ParseQuery(query)
+	if err != nil {
+		return models.Filters{}, badRequest(CodeInvalidQuery, "query must be a URL query string")
+	}
+
+	selective := false
+	for key, values := range q {
+		switch {
+		case slices.Contains(bulkFilterKeys, key):
+			selective = true
+		case !slices.Contains(bulkMatchKeys, key):
+			return models.Filters{}, badRequest(CodeInvalidQuery, fmt.Sprintf("query parameter %q is not supported in bulk operations", key))
+		}
+		if len(values) != 1 || values[0] == "" {
+			return models.Filters{}, badRequest(CodeInvalidQuery, fmt.Sprintf("query parameter %q must have exactly one non-empty value", key))
+		}
+	}
+	// Поиск пропускает неразбираемые id и age, в массовой операции это означало бы отсутствие фильтра
+	for _, key := range []string{"id", "age"} {
+		if _, err := parseOptionalInt(q, key); err != nil {
+			return models.Filters{}, err
+		}
+	}
+	if !selective && !all {
+		return models.Filters{}, badRequest(CodeInvalidQuery, "query contains no filters, set all to true to apply the operation to every person")
+	}
+
+	filters, err := parseFilters(q)
+	if err != nil {
+		return models.Filters{}, err
+	}
+	filters.Limit, filters.Offset = 0, 0
+	return filters, nil
+}
+
+// bulkChanges проверяет поля массового изменения по тем же правилам, что и при редактировании
+// записи, и возвращает их в формате repository.BulkUpdatePeople
+func bulkChanges(set map[string]any) (map[string]any, error) {
+	if len(set) == 0 {
+		return nil, badRequest(CodeInvalidBody, "set must contain at least one field")
+	}
+
+	changes, err := personChanges(map[string]any{}, set)
+	if err != nil {
+		return nil, badRequest(CodeInvalidBody, "Invalid set: "+err.Error())
+	}
+
+	// Значения проверяются на записи-заготовке, ошибки остальных полей не учитываются
+	doc := map[string]any{"name": "Name", "surname": "Surname"}
+	for field, value := range set {
+		if value != nil {
+			doc[field] = value
+		}
+	}
+	person, err := personFromDocument(doc)
+	if err != nil {
+		return nil, badRequest(CodeInvalidBody, fmt.Sprintf("Invalid set: %s", err.Error()))
+	}
+	var errs []models.FieldError
+	for _, e := range validation.Validate(person) {
+		if _, ok := set[e.Field]; ok {
+			errs = append(errs, e)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, validationFailed(errs)
+	}
+
+	return changes, nil
+}
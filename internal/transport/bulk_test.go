@@ -0,0 +1,97 @@
+package transport
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"people-credentials-api/internal/models"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// -----------------------
+// Тесты массовых операций
+// -----------------------
+func TestBulkRequestSelectors(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/persons/bulk-delete", strings.NewReader(`{"ids":[3,1],"dry_run":true}`))
+	payload, filters, err := bulkRequest(r)
+	assert.NoError(t, err)
+	assert.True(t, payload.DryRun)
+	assert.Equal(t, models.Filters{IDs: []int{3, 1}}, filters)
+
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/persons/bulk-delete", strings.NewReader(`{"query":"gender=female&age_min=90"}`))
+	_, filters, err = bulkRequest(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "female", filters.Gender)
+	assert.Equal(t, 90, *filters.AgeMin)
+	assert.Zero(t, filters.Limit)
+
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/persons/bulk-delete", strings.NewReader(`{"all":true}`))
+	_, filters, err = bulkRequest(r)
+	assert.NoError(t, err)
+	assert.Equal(t, models.Filters{NameMatch: models.MatchContains, SurnameMatch: models.MatchContains, PatronymicMatch: models.MatchContains}, filters)
+}
+
+func TestBulkRequestErrors(t *testing.T) {
+	for body, code := range map[string]string{
+		`[]`:                           CodeInvalidBody,
+		`{}`:                           CodeInvalidBody,
+		`{"ids":[1],"query":"age=1"}`:  CodeInvalidBody,
+		`{"ids":[0]}`:                  CodeInvalidBody,
+		`{"query":"sort=salary"}`:      CodeInvalidQuery,
+		`{"ids":[1],"all":true}`:       CodeInvalidBody,
+		`{"query":"nme=ivan"}`:         CodeInvalidQuery,
+		`{"query":"page=2"}`:           CodeInvalidQuery,
+		`{"query":"name_match=exact"}`: CodeInvalidQuery,
+		`{"query":"age=abc"}`:          CodeInvalidQuery,
+		`{"query":"id=1x"}`:            CodeInvalidQuery,
+		`{"query":"gender=in:"}`:       CodeInvalidQuery,
+		`{"query":"name="}`:            CodeInvalidQuery,
+		`{"query":"name=a&name=b"}`:    CodeInvalidQuery,
+		`{"ids":[` + strings.Repeat("1,", 1000) + `1]}`: CodeBulkLimitExceeded,
+	} {
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/persons/bulk-delete", strings.NewReader(body))
+		_, _, err := bulkRequest(r)
+		assert.Equal(t, code, problemFromError(err).Code, body)
+	}
+}
+
+func TestBulkChanges(t *testing.T) {
+	changes, err := bulkChanges(map[string]any{"nationality": "KZ", "age": nil, "patronymic": nil})
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"nationality": "KZ", "age": nil, "patronymic": nil}, changes)
+}
+
+func TestBulkChangesStoresEmptyValuesAsNull(t *testing.T) {
+	changes, err := bulkChanges(map[string]any{"gender": "", "age": float64(0), "patronymic": ""})
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"gender": nil, "age": nil, "patronymic": nil}, changes)
+}
+
+func TestBulkChangesErrors(t *testing.T) {
+	for _, tc := range []struct {
+		set  map[string]any
+		code string
+	}{
+		{nil, CodeInvalidBody},
+		{map[string]any{"id": float64(1)}, CodeInvalidBody},
+		{map[string]any{"name": nil}, CodeInvalidBody},
+		{map[string]any{"age": 1.5}, CodeInvalidBody},
+		{map[string]any{"gender": "unknown"}, CodeValidationFailed},
+		{map[string]any{"nationality": "kz"}, CodeValidationFailed},
+	} {
+		_, err := bulkChanges(tc.set)
+		assert.Equal(t, tc.code, problemFromError(err).Code, tc.set)
+	}
+}
+
+func TestBulkDeleteRejectsSet(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newRouter().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/persons/bulk-delete",
+		strings.NewReader(`{"ids":[1],"set":{"age":30}}`)))
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
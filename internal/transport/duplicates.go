@@ -0,0 +1,133 @@
+package transport
+
+import (
+	"fmt"
+	"net/http"
+	"people-credentials-api/internal/config"
+	"people-credentials-api/internal/models"
+	"people-credentials-api/internal/repository"
+	"slices"
+	"strconv"
+)
+
+const (
+	// maxDuplicateCandidates - сколько возможных дубликатов возвращается при создании записи
+	maxDuplicateCandidates = 10
+	defaultDuplicatePairs  = 100
+	maxDuplicatePairs      = 1000
+)
+
+// findDuplicates проверяет создаваемую запись на дубликаты согласно политике из конфигурации.
+// При политике reject найденные дубликаты возвращаются ошибкой 409, при warn - списком для ответа.
+// Параметр force=true и политика allow отключают проверку
+func findDuplicates(r *http.Request, payload models.InsertPersonRequest) ([]models.DuplicateCandidate, error) {
+	cfg := config.Get()
+	force, err := parseBool(r.URL.Query(), "force")
+	if err != nil {
+		return nil, err
+	}
+	if force || cfg.DuplicatePolicy == config.DuplicatePolicyAllow {
+		return nil, nil
+	}
+
+	duplicates, err := repository.FindDuplicates(r.Context(), payload.Name, payload.Surname, payload.Patronymic,
+		cfg.DuplicateThreshold, maxDuplicateCandidates)
+	if err != nil {
+		return nil, err
+	}
+	if len(duplicates) > 0 && cfg.DuplicatePolicy == config.DuplicatePolicyReject {
+		return nil, duplicatesFound(r, duplicates)
+	}
+	return duplicates, nil
+}
+
+// insertPerson сохраняет созданную запись. При политике reject дубликаты проверяются ещё раз
+// в одной транзакции со вставкой: пока запись обогащалась, её дубликат мог создать другой запрос
+func insertPerson(r *http.Request, person models.Person) (models.Person, error) {
+	cfg := config.Get()
+	force, err := parseBool(r.URL.Query(), "force")
+	if err != nil {
+		return models.Person{}, err
+	}
+	if force || cfg.DuplicatePolicy != config.DuplicatePolicyReject {
+		return repository.InsertPerson(r.Context(), person)
+	}
+
+	inserted, duplicates, err := repository.InsertPersonWithoutDuplicates(r.Context(), person,
+		cfg.DuplicateThreshold, maxDuplicateCandidates)
+	if err != nil {
+		return models.Person{}, err
+	}
+	if len(duplicates) > 0 {
+		return models.Person{}, duplicatesFound(r, duplicates)
+	}
+	return inserted, nil
+}
+
+// duplicatesFound возвращает ошибку 409 со списком возможных дубликатов
+// и записывает их ID в журнал аудита, так как их данные попадают в ответ
+func duplicatesFound(r *http.Request, duplicates []models.DuplicateCandidate) error {
+	auditDuplicates(r, duplicates)
+	return &requestError{
+		status:     http.StatusConflict,
+		code:       CodeDuplicatePerson,
+		detail:     fmt.Sprintf("Found %d possible duplicates, repeat with force=true to create the person anyway", len(duplicates)),
+		duplicates: duplicates,
+	}
+}
+
+// auditDuplicates передаёт журналу аудита ID возможных дубликатов, возвращённых клиенту
+func auditDuplicates(r *http.Request, duplicates []models.DuplicateCandidate) {
+	ids := make([]int, len(duplicates))
+	for i, d := range duplicates {
+		ids[i] = d.Person.ID
+	}
+	auditTargets(r, ids)
+}
+
+// DuplicatesReportHandler godoc
+// @Summary Possible Duplicate Persons
+// @Description Lists pairs of existing persons whose normalized full names are equal or similar, most similar first.
+// @Description Deleted persons are not included.
+// @Tags person
+// @Produce json
+// @Produce application/problem+json
+// @Param threshold query number false "Minimum trigram similarity of full names from 0.3 to 1 (default from configuration)"
+// @Param limit query int false "Maximum number of pairs to return (default 100)"
+// @Success 200 {object} models.DuplicatesReport "Possible duplicates"
+// @Failure 400 {object} models.Problem "Bad Request"
+// @Failure 500 {object} models.Problem "Internal Server Error"
+// @Router /api/v1/persons/duplicates [get]
+func DuplicatesReportHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit, err := parseIntParam(q, "limit", defaultDuplicatePairs, 1, maxDuplicatePairs)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+	threshold := config.Get().DuplicateThreshold
+	if value := q.Get("threshold"); value != "" {
+		threshold, err = strconv.ParseFloat(value, 64)
+		if err != nil || threshold < config.MinDuplicateThreshold || threshold > config.MaxDuplicateThreshold {
+			ErrorResponse(w, r, badRequest(CodeInvalidQuery, fmt.Sprintf("threshold must be a number between %g and %g",
+				config.MinDuplicateThreshold, config.MaxDuplicateThreshold)))
+			return
+		}
+	}
+
+	report, err := repository.GetDuplicatesReport(r.Context(), threshold, limit)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	// Запись может входить в несколько пар, в журнал её ID попадает один раз
+	ids := make([]int, 0, 2*len(report.Pairs))
+	for _, pair := range report.Pairs {
+		ids = append(ids, pair.First.ID, pair.Second.ID)
+	}
+	slices.Sort(ids)
+	auditTargets(r, slices.Compact(ids))
+
+	jsonResponse(w, http.StatusOK, report)
+}
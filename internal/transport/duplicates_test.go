@@ -0,0 +1,86 @@
+package transport
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"people-credentials-api/internal/config"
+	"people-credentials-api/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// -----------------------
+// Тесты поиска дубликатов
+// -----------------------
+func TestFindDuplicatesSkippedByPolicyOrForce(t *testing.T) {
+	cfg := config.Get()
+	defer func(policy string) { cfg.DuplicatePolicy = policy }(cfg.DuplicatePolicy)
+	payload := models.InsertPersonRequest{Name: "Vladislav", Surname: "Bezmaternih"}
+
+	cfg.DuplicatePolicy = config.DuplicatePolicyAllow
+	duplicates, err := findDuplicates(httptest.NewRequest(http.MethodPost, "/api/v1/persons", nil), payload)
+	assert.NoError(t, err)
+	assert.Empty(t, duplicates)
+
+	cfg.DuplicatePolicy = config.DuplicatePolicyReject
+	duplicates, err = findDuplicates(httptest.NewRequest(http.MethodPost, "/api/v1/persons?force=true", nil), payload)
+	assert.NoError(t, err)
+	assert.Empty(t, duplicates)
+
+	_, err = findDuplicates(httptest.NewRequest(http.MethodPost, "/api/v1/persons?force=maybe", nil), payload)
+	assert.Equal(t, CodeInvalidQuery, problemFromError(err).Code)
+}
+
+func TestDuplicateProblemListsCandidates(t *testing.T) {
+	err := &requestError{
+		status:     http.StatusConflict,
+		code:       CodeDuplicatePerson,
+		detail:     "Found 1 possible duplicates",
+		duplicates: []models.DuplicateCandidate{{Person: models.Person{ID: 7, Name: "Vladislav"}, Similarity: 1, Exact: true}},
+	}
+
+	problem := problemFromError(err)
+
+	assert.Equal(t, http.StatusConflict, problem.Status)
+	assert.Equal(t, CodeDuplicatePerson, problem.Code)
+	assert.Len(t, problem.Duplicates, 1)
+	assert.Equal(t, 7, problem.Duplicates[0].Person.ID)
+}
+
+func TestDuplicatesFoundRecordsCandidatesInAudit(t *testing.T) {
+	entries := recordAudit(t)
+	router := http.NewServeMux()
+	router.HandleFunc("POST /api/v1/persons", audited("person.create", func(w http.ResponseWriter, r *http.Request) {
+		ErrorResponse(w, r, duplicatesFound(r, []models.DuplicateCandidate{
+			{Person: models.Person{ID: 7}, Similarity: 1, Exact: true},
+			{Person: models.Person{ID: 9}, Similarity: 0.8},
+		}))
+	}))
+	withAudit(true, router).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/v1/persons", nil))
+
+	assert.Len(t, *entries, 1)
+	assert.Equal(t, http.StatusConflict, (*entries)[0].Status)
+	assert.Equal(t, []int{7, 9}, (*entries)[0].TargetIDs)
+}
+
+func TestCreatePersonResponseKeepsPersonFields(t *testing.T) {
+	body, err := json.Marshal(models.CreatePersonResponse{Person: models.Person{ID: 3, Name: "Ivan"}})
+	assert.NoError(t, err)
+
+	var doc map[string]any
+	assert.NoError(t, json.Unmarshal(body, &doc))
+	assert.Equal(t, float64(3), doc["id"])
+	assert.Equal(t, "Ivan", doc["name"])
+	assert.NotContains(t, doc, "possible_duplicates")
+}
+
+func TestDuplicatesReportRejectsInvalidParameters(t *testing.T) {
+	for _, query := range []string{"threshold=0.1", "threshold=abc", "limit=0", "limit=100000"} {
+		rec := httptest.NewRecorder()
+		newRouter().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/persons/duplicates?"+query, nil))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+}
@@ -0,0 +1,147 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"people-credentials-api/internal/enricher"
+	"people-credentials-api/internal/models"
+	"people-credentials-api/internal/repository"
+	"people-credentials-api/pkg/logger"
+)
+
+// Стабильные коды ошибок API, передаются клиенту в поле code
+const (
+	CodeInvalidID            = "invalid_id"
+	CodeInvalidQuery         = "invalid_query"
+	CodeInvalidCursor        = "invalid_cursor"
+	CodeInvalidFilter        = "invalid_filter"
+	CodeInvalidBody          = "invalid_body"
+	CodeMalformedRow         = "malformed_row"
+	CodeTooManyRows          = "too_many_rows"
+	CodeBodyTooLarge         = "body_too_large"
+	CodeInvalidPatch         = "invalid_patch"
+	CodeUnsupportedMediaType = "unsupported_media_type"
+	CodeNotAcceptable        = "not_acceptable"
+	CodeValidationFailed     = "validation_failed"
+	CodeBulkLimitExceeded    = "bulk_limit_exceeded"
+	CodeInvalidIfMatch       = "invalid_if_match"
+	CodeIfMatchRequired      = "if_match_required"
+	CodeActorRequired        = "actor_required"
+	CodeForbidden            = "forbidden"
+	CodePersonNotFound       = "person_not_found"
+	CodeSavedSearchNotFound  = "saved_search_not_found"
+	CodeInvalidSavedSearch   = "invalid_saved_search"
+	CodeVersionNotFound      = "version_not_found"
+	CodeVersionConflict      = "version_conflict"
+	CodePersonNotDeleted     = "person_not_deleted"
+	CodeDuplicatePerson      = "duplicate_person"
+	CodeEnrichmentFailed     = "enrichment_failed"
+	CodeInsertFailed         = "insert_failed"
+	CodeTimeout              = "timeout"
+	CodeRequestCanceled      = "request_canceled"
+	CodeInternalError        = "internal_error"
+)
+
+// StatusClientClosedRequest - нестандартный статус 499 (как в nginx) для запросов,
+// прерванных самим клиентом: такой ответ клиент уже не получит, но он попадает в логи и журнал аудита
+const StatusClientClosedRequest = 499
+
+// requestError - ошибка, обнаруженная при разборе запроса в транспортном слое
+type requestError struct {
+	status     int
+	code       string
+	detail     string
+	fields     []models.FieldError
+	duplicates []models.DuplicateCandidate
+}
+
+func (e *requestError) Error() string {
+	return e.detail
+}
+
+func badRequest(code, detail string) error {
+	return &requestError{status: http.StatusBadRequest, code: code, detail: detail}
+}
+
+func validationFailed(fields []models.FieldError) error {
+	return &requestError{
+		status: http.StatusUnprocessableEntity,
+		code:   CodeValidationFailed,
+		detail: "Request validation failed",
+		fields: fields,
+	}
+}
+
+// sentinelErrors сопоставляет ошибки нижележащих слоёв HTTP-статусам и кодам.
+// Порядок важен: таймаут внешнего API - это и ошибка обогащения, и истёкший дедлайн.
+// Отмена запроса клиентом - ошибка клиента, а не недоступность сервиса.
+var sentinelErrors = []struct {
+	err    error
+	status int
+	code   string
+	detail string
+}{
+	{context.DeadlineExceeded, http.StatusGatewayTimeout, CodeTimeout, "Request processing timed out"},
+	{context.Canceled, StatusClientClosedRequest, CodeRequestCanceled, "Request was canceled"},
+	{repository.ErrInvalidCursor, http.StatusBadRequest, CodeInvalidCursor, "Invalid pagination cursor"},
+	{repository.ErrPersonNotFound, http.StatusNotFound, CodePersonNotFound, "Person not found"},
+	{repository.ErrSavedSearchNotFound, http.StatusNotFound, CodeSavedSearchNotFound, "Saved search not found"},
+	{repository.ErrHistoryVersionNotFound, http.StatusNotFound, CodeVersionNotFound, "Person version not found in history"},
+	{repository.ErrNotSavedSearchOwner, http.StatusForbidden, CodeForbidden, "Saved search belongs to another owner"},
+	{repository.ErrBulkLimitExceeded, http.StatusUnprocessableEntity, CodeBulkLimitExceeded, "Operation selects more persons than allowed"},
+	{repository.ErrVersionConflict, http.StatusPreconditionFailed, CodeVersionConflict, "Person has been modified, fetch it again and retry"},
+	{repository.ErrPersonNotDeleted, http.StatusConflict, CodePersonNotDeleted, "Person is not deleted"},
+	{enricher.ErrEnrichmentFailed, http.StatusBadGateway, CodeEnrichmentFailed, "Failed to enrich person data"},
+}
+
+// problemFromError строит ответ problem+json по ошибке любого слоя.
+// Неизвестные ошибки превращаются в 500 без раскрытия деталей клиенту.
+func problemFromError(err error) models.Problem {
+	var reqErr *requestError
+	if errors.As(err, &reqErr) {
+		problem := newProblem(reqErr.status, reqErr.code, reqErr.detail, reqErr.fields)
+		problem.Duplicates = reqErr.duplicates
+		return problem
+	}
+	for _, s := range sentinelErrors {
+		if errors.Is(err, s.err) {
+			return newProblem(s.status, s.code, s.detail, nil)
+		}
+	}
+	return newProblem(http.StatusInternalServerError, CodeInternalError, "Internal Server Error", nil)
+}
+
+func newProblem(status int, code, detail string, fields []models.FieldError) models.Problem {
+	title := http.StatusText(status)
+	if status == StatusClientClosedRequest {
+		title = "Client Closed Request"
+	}
+	return models.Problem{
+		Type:   "about:blank",
+		Title:  title,
+		Status: status,
+		Detail: detail,
+		Code:   code,
+		Errors: fields,
+	}
+}
+
+// ErrorResponse отвечает на ошибку в формате RFC 7807 (application/problem+json)
+func ErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	problem := problemFromError(err)
+	problem.Instance = r.URL.Path
+	problem.RequestID = requestIDFromContext(r.Context())
+
+	if problem.Status >= http.StatusInternalServerError {
+		logger.Error(fmt.Sprintf("Request %s %s (%s) failed: %s", r.Method, r.URL.Path, problem.RequestID, err.Error()))
+	}
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(problem.Status)
+	if err := json.NewEncoder(w).Encode(problem); err != nil {
+		logger.Error("Failed to encode error response: " + err.Error())
+	}
+}
@@ -0,0 +1,66 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"people-credentials-api/internal/enricher"
+	"people-credentials-api/internal/models"
+	"people-credentials-api/internal/repository"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// --------------------------
+// Тесты сопоставления ошибок
+// --------------------------
+func TestProblemFromError(t *testing.T) {
+	cases := map[string]struct {
+		err    error
+		status int
+		code   string
+	}{
+		"request error":      {badRequest(CodeInvalidID, "Invalid id"), http.StatusBadRequest, CodeInvalidID},
+		"not found":          {fmt.Errorf("wrapped: %w", repository.ErrPersonNotFound), http.StatusNotFound, CodePersonNotFound},
+		"version conflict":   {repository.ErrVersionConflict, http.StatusPreconditionFailed, CodeVersionConflict},
+		"enrichment":         {fmt.Errorf("%w: boom", enricher.ErrEnrichmentFailed), http.StatusBadGateway, CodeEnrichmentFailed},
+		"enrichment timeout": {fmt.Errorf("%w: %w", enricher.ErrEnrichmentFailed, context.DeadlineExceeded), http.StatusGatewayTimeout, CodeTimeout},
+		"unknown":            {errors.New("pq: relation people does not exist"), http.StatusInternalServerError, CodeInternalError},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			p := problemFromError(c.err)
+			assert.Equal(t, c.status, p.Status)
+			assert.Equal(t, c.code, p.Code)
+			assert.Equal(t, http.StatusText(c.status), p.Title)
+		})
+	}
+}
+
+func TestProblemFromCanceledRequest(t *testing.T) {
+	p := problemFromError(fmt.Errorf("query: %w", context.Canceled))
+
+	assert.Equal(t, StatusClientClosedRequest, p.Status)
+	assert.Equal(t, CodeRequestCanceled, p.Code)
+	assert.Equal(t, "Client Closed Request", p.Title)
+}
+
+func TestErrorResponseDoesNotLeakInternalErrors(t *testing.T) {
+	rec := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/search", nil)
+	r = r.WithContext(context.WithValue(r.Context(), requestIDKey, "req-1"))
+
+	ErrorResponse(rec, r, errors.New("pq: password authentication failed"))
+
+	var p models.Problem
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&p))
+	assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "req-1", p.RequestID)
+	assert.Equal(t, "/api/v1/search", p.Instance)
+	assert.NotContains(t, p.Detail, "pq")
+}
@@ -0,0 +1,50 @@
+package transport
+
+import (
+	"net/http"
+	"people-credentials-api/internal/config"
+	"strconv"
+	"strings"
+)
+
+var (
+	errIfMatchRequired error = &requestError{
+		status: http.StatusPreconditionRequired,
+		code:   CodeIfMatchRequired,
+		detail: "If-Match header is required",
+	}
+	errInvalidIfMatch = badRequest(CodeInvalidIfMatch, "Invalid If-Match header")
+)
+
+// etag формирует значение заголовка ETag по версии записи
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// expectedVersion извлекает ожидаемую версию записи из заголовка If-Match.
+// Возвращает 0, если версия не проверяется (заголовок отсутствует или равен "*").
+// Слабые ETag не совпадают при строгом сравнении, поэтому им соответствует версия -1.
+func expectedVersion(r *http.Request) (int, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" {
+		if config.Get().RequireIfMatch {
+			return 0, errIfMatchRequired
+		}
+		return 0, nil
+	}
+	if value == "*" {
+		return 0, nil
+	}
+	if strings.HasPrefix(value, "W/") {
+		return -1, nil
+	}
+
+	if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return 0, errInvalidIfMatch
+	}
+	version, err := strconv.Atoi(value[1 : len(value)-1])
+	if err != nil || version <= 0 {
+		return 0, errInvalidIfMatch
+	}
+	return version, nil
+}
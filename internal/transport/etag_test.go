@@ -0,0 +1,50 @@
+package transport
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ----------------------
+// Тесты разбора If-Match
+// ----------------------
+func TestExpectedVersion(t *testing.T) {
+	cases := map[string]struct {
+		header  string
+		version int
+		err     error
+	}{
+		"absent":   {header: "", version: 0},
+		"wildcard": {header: "*", version: 0},
+		"strong":   {header: `"3"`, version: 3},
+		"weak":     {header: `W/"3"`, version: -1},
+		"unquoted": {header: "3", err: errInvalidIfMatch},
+		"garbage":  {header: `"abc"`, err: errInvalidIfMatch},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest("PUT", "/api/v1/persons/1", nil)
+			if c.header != "" {
+				r.Header.Set("If-Match", c.header)
+			}
+
+			version, err := expectedVersion(r)
+
+			assert.Equal(t, c.err, err)
+			assert.Equal(t, c.version, version)
+		})
+	}
+}
+
+func TestETagRoundTrip(t *testing.T) {
+	r := httptest.NewRequest("PUT", "/api/v1/persons/1", nil)
+	r.Header.Set("If-Match", etag(42))
+
+	version, err := expectedVersion(r)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 42, version)
+}
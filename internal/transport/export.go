@@ -0,0 +1,284 @@
+package transport
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"people-credentials-api/internal/models"
+	"people-credentials-api/internal/repository"
+	"people-credentials-api/pkg/logger"
+	"people-credentials-api/pkg/xlsx"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// exportFormat - формат выгрузки: MIME-тип, расширение файла и конструктор записи
+type exportFormat struct {
+	mediaType string
+	extension string
+	newWriter func(w io.Writer, fields []string, projected bool) exportWriter
+}
+
+var exportFormats = map[string]exportFormat{
+	"csv": {"text/csv", "csv", func(w io.Writer, fields []string, _ bool) exportWriter {
+		return &csvExport{w: csv.NewWriter(w), fields: fields}
+	}},
+	"ndjson": {"application/x-ndjson", "ndjson", func(w io.Writer, fields []string, projected bool) exportWriter {
+		return &ndjsonExport{enc: json.NewEncoder(w), fields: fields, projected: projected}
+	}},
+	"xlsx": {"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", "xlsx", func(w io.Writer, fields []string, _ bool) exportWriter {
+		return &xlsxExport{w: w, fields: fields}
+	}},
+}
+
+// ExportPersonsHandler godoc
+// @Summary Export Persons
+// @Description Streams all persons matching the search filters as CSV, NDJSON or XLSX.
+// @Description The format is chosen by the format parameter or by the Accept header, CSV is used by default.
+// @Tags person
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Produce application/problem+json
+// @Param format query string false "Export format: csv, ndjson or xlsx, takes precedence over Accept"
+// @Param fields query string false "Comma-separated fields to export, e.g. id,name,surname"
+// @Param sort query string false "Comma-separated sort fields, prefix with - for descending order (e.g. -age,surname)"
+// @Param q query string false "Full-text search across name, surname and patronymic"
+// @Param gender query string false "Filter by gender, or by a set of genders as in:male,female"
+// @Param nationality query string false "Filter by nationality, or by a set of country codes as in:RU,UA,KZ"
+// @Param filter query string false "Boolean filter expression, e.g. (gender:female AND age>=30) OR nationality:KZ"
+// @Success 200 {file} file "Exported persons"
+// @Failure 400 {object} models.Problem "Bad Request"
+// @Failure 406 {object} models.Problem "Not Acceptable"
+// @Failure 500 {object} models.Problem "Internal Server Error"
+// @Router /api/v1/persons/export [get]
+func ExportPersonsHandler(w http.ResponseWriter, r *http.Request) {
+	format, err := negotiateExportFormat(r)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+	filters, err := buildFiltersFromQuery(r)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	fields := filters.Fields
+	if len(fields) == 0 {
+		// Время удаления выгружается, только если удалённые записи запрошены явно
+		fields = slices.DeleteFunc(repository.ProjectableFields(), func(field string) bool {
+			return field == "deleted_at" && !filters.IncludeDeleted
+		})
+	}
+
+	// Ответ начинается только с первой пачкой записей, чтобы ошибка запроса
+	// ещё могла быть возвращена в формате problem+json
+	var out exportWriter
+	start := func() error {
+		w.Header().Set("Content-Type", format.mediaType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="persons.%s"`, format.extension))
+		out = format.newWriter(w, fields, len(filters.Fields) > 0)
+		return out.writeHeader()
+	}
+	flusher, _ := w.(http.Flusher)
+
+	err = repository.StreamPeople(r.Context(), filters, func(batch []models.Person) error {
+		if out == nil {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		ids := make([]int, 0, len(batch))
+		for _, p := range batch {
+			if err := out.write(p); err != nil {
+				return err
+			}
+			ids = append(ids, p.ID)
+		}
+		auditTargets(r, ids)
+		if err := out.flush(); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err != nil && out == nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+	if err != nil {
+		// Ответ уже частично отправлен: обрываем соединение, чтобы клиент
+		// не принял усечённый файл за полный
+		logger.Error(fmt.Sprintf("Export %s (%s) failed: %s", r.URL.String(), requestIDFromContext(r.Context()), err.Error()))
+		panic(http.ErrAbortHandler)
+	}
+
+	if out == nil {
+		if err := start(); err != nil {
+			logger.Error("Failed to write export: " + err.Error())
+			return
+		}
+	}
+	if err := out.close(); err != nil {
+		logger.Error("Failed to write export: " + err.Error())
+	}
+}
+
+// negotiateExportFormat выбирает формат выгрузки по параметру format или заголовку Accept.
+// Типы в Accept перебираются в порядке перечисления, типы с q=0 пропускаются
+func negotiateExportFormat(r *http.Request) (exportFormat, error) {
+	if name := r.URL.Query().Get("format"); name != "" {
+		format, ok := exportFormats[name]
+		if !ok {
+			return exportFormat{}, badRequest(CodeInvalidQuery, "format must be one of: csv, ndjson, xlsx")
+		}
+		return format, nil
+	}
+
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return exportFormats["csv"], nil
+	}
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil || params["q"] == "0" {
+			continue
+		}
+		if mediaType == "*/*" || mediaType == "text/*" {
+			return exportFormats["csv"], nil
+		}
+		for _, format := range exportFormats {
+			if format.mediaType == mediaType {
+				return format, nil
+			}
+		}
+	}
+	return exportFormat{}, &requestError{
+		status: http.StatusNotAcceptable,
+		code:   CodeNotAcceptable,
+		detail: "Export is available as text/csv, application/x-ndjson or application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	}
+}
+
+// exportWriter записывает выгрузку построчно
+type exportWriter interface {
+	writeHeader() error
+	write(p models.Person) error
+	flush() error
+	close() error
+}
+
+type csvExport struct {
+	w      *csv.Writer
+	fields []string
+}
+
+func (e *csvExport) writeHeader() error {
+	return e.w.Write(e.fields)
+}
+
+func (e *csvExport) write(p models.Person) error {
+	record := make([]string, len(e.fields))
+	for i, field := range e.fields {
+		switch v := fieldValue(p, field).(type) {
+		case int:
+			record[i] = strconv.Itoa(v)
+		case time.Time:
+			record[i] = v.Format(time.RFC3339)
+		case *time.Time:
+			if v != nil {
+				record[i] = v.Format(time.RFC3339)
+			}
+		default:
+			record[i] = fmt.Sprint(v)
+		}
+	}
+	return e.w.Write(record)
+}
+
+func (e *csvExport) flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvExport) close() error {
+	return e.flush()
+}
+
+type ndjsonExport struct {
+	enc       *json.Encoder
+	fields    []string
+	projected bool
+}
+
+func (e *ndjsonExport) writeHeader() error {
+	return nil
+}
+
+func (e *ndjsonExport) write(p models.Person) error {
+	if e.projected {
+		return e.enc.Encode(projectPerson(p, e.fields))
+	}
+	return e.enc.Encode(p)
+}
+
+func (e *ndjsonExport) flush() error {
+	return nil
+}
+
+func (e *ndjsonExport) close() error {
+	return nil
+}
+
+type xlsxExport struct {
+	w      io.Writer
+	sheet  *xlsx.Writer
+	fields []string
+}
+
+func (e *xlsxExport) writeHeader() error {
+	sheet, err := xlsx.NewWriter(e.w, "Persons")
+	if err != nil {
+		return err
+	}
+	e.sheet = sheet
+
+	header := make([]any, len(e.fields))
+	for i, field := range e.fields {
+		header[i] = field
+	}
+	return e.sheet.WriteRow(header...)
+}
+
+func (e *xlsxExport) write(p models.Person) error {
+	row := make([]any, len(e.fields))
+	for i, field := range e.fields {
+		switch v := fieldValue(p, field).(type) {
+		case time.Time:
+			row[i] = v.Format(time.RFC3339)
+		case *time.Time:
+			if v != nil {
+				row[i] = v.Format(time.RFC3339)
+			}
+		default:
+			row[i] = v
+		}
+	}
+	return e.sheet.WriteRow(row...)
+}
+
+func (e *xlsxExport) flush() error {
+	return e.sheet.Flush()
+}
+
+func (e *xlsxExport) close() error {
+	return e.sheet.Close()
+}
@@ -0,0 +1,87 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"people-credentials-api/internal/config"
+	"people-credentials-api/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// --------------
+// Тесты выгрузки
+// --------------
+func TestNegotiateExportFormat(t *testing.T) {
+	for _, tc := range []struct {
+		target, accept, extension string
+	}{
+		{"/api/v1/persons/export", "", "csv"},
+		{"/api/v1/persons/export", "*/*", "csv"},
+		{"/api/v1/persons/export", "application/x-ndjson", "ndjson"},
+		{"/api/v1/persons/export", "text/csv;q=0, application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", "xlsx"},
+		{"/api/v1/persons/export?format=ndjson", "text/csv", "ndjson"},
+	} {
+		r := httptest.NewRequest(http.MethodGet, tc.target, nil)
+		r.Header.Set("Accept", tc.accept)
+
+		format, err := negotiateExportFormat(r)
+		assert.NoError(t, err, tc.accept)
+		assert.Equal(t, tc.extension, format.extension, tc.accept)
+	}
+}
+
+func TestNegotiateExportFormatErrors(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/persons/export", nil)
+	r.Header.Set("Accept", "application/pdf")
+	_, err := negotiateExportFormat(r)
+	assert.Equal(t, http.StatusNotAcceptable, problemFromError(err).Status)
+
+	r = httptest.NewRequest(http.MethodGet, "/api/v1/persons/export?format=pdf", nil)
+	_, err = negotiateExportFormat(r)
+	assert.Equal(t, CodeInvalidQuery, problemFromError(err).Code)
+}
+
+func TestCSVExport(t *testing.T) {
+	var buf bytes.Buffer
+	out := exportFormats["csv"].newWriter(&buf, []string{"id", "surname", "created_at"}, true)
+
+	assert.NoError(t, out.writeHeader())
+	assert.NoError(t, out.write(models.Person{ID: 1, Surname: "O'Neil, Jr", CreatedAt: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)}))
+	assert.NoError(t, out.close())
+
+	assert.Equal(t, "id,surname,created_at\n1,\"O'Neil, Jr\",2024-05-01T10:00:00Z\n", buf.String())
+}
+
+func TestNDJSONExport(t *testing.T) {
+	var buf bytes.Buffer
+	out := exportFormats["ndjson"].newWriter(&buf, []string{"id", "name"}, true)
+
+	assert.NoError(t, out.writeHeader())
+	assert.NoError(t, out.write(models.Person{ID: 1, Name: "Ivan"}))
+	assert.NoError(t, out.write(models.Person{ID: 2, Name: "Petr"}))
+	assert.NoError(t, out.close())
+
+	assert.Equal(t, "{\"id\":1,\"name\":\"Ivan\"}\n{\"id\":2,\"name\":\"Petr\"}\n", buf.String())
+}
+
+func TestExportHasLongerTimeout(t *testing.T) {
+	var deadlines []time.Duration
+	router := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deadline, _ := r.Context().Deadline()
+		deadlines = append(deadlines, time.Until(deadline))
+	})
+	handler := withTimeouts(&config.Config{RequestTimeout: time.Second, ExportTimeout: time.Hour}, router)
+
+	for _, target := range []string{"/api/v1/persons", "/api/v1/persons/export"} {
+		r := httptest.NewRequest(http.MethodGet, target, nil).WithContext(context.Background())
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	assert.LessOrEqual(t, deadlines[0], time.Second)
+	assert.Greater(t, deadlines[1], time.Minute)
+}
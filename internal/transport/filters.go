@@ -0,0 +1,374 @@
+package transport
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"people-credentials-api/internal/config"
+	"people-credentials-api/internal/filterexpr"
+	"people-credentials-api/internal/models"
+	"people-credentials-api/internal/repository"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultPageSize = 20
+
+func buildFiltersFromQuery(r *http.Request) (models.Filters, error) {
+	f, err := parseFilters(r.URL.Query())
+	if err != nil {
+		return f, err
+	}
+	return f, authorizeFilters(r, f)
+}
+
+// parseFilters разбирает параметры поиска в формате строки запроса GET /api/v1/search
+func parseFilters(q url.Values) (models.Filters, error) {
+	var f models.Filters
+
+	if id := q.Get("id"); id != "" {
+		if v, err := strconv.Atoi(id); err == nil {
+			f.ID = v
+		}
+	}
+	if text := q.Get("q"); text != "" {
+		f.Q = text
+	}
+	if name := q.Get("name"); name != "" {
+		f.Name = name
+	}
+	if surname := q.Get("surname"); surname != "" {
+		f.Surname = surname
+	}
+	if patronymic := q.Get("patronymic"); patronymic != "" {
+		f.Patronymic = patronymic
+	}
+	for key, mode := range map[string]*models.MatchMode{
+		"name_match":       &f.NameMatch,
+		"surname_match":    &f.SurnameMatch,
+		"patronymic_match": &f.PatronymicMatch,
+	} {
+		m, err := parseMatchMode(q, key)
+		if err != nil {
+			return f, err
+		}
+		*mode = m
+	}
+	if age := q.Get("age"); age != "" {
+		if v, err := strconv.Atoi(age); err == nil {
+			f.Age = v
+		}
+	}
+	var err error
+	if gender := q.Get("gender"); gender != "" {
+		if f.Genders, err = parseSet("gender", gender); err != nil {
+			return f, err
+		}
+		if f.Genders == nil {
+			f.Gender = gender
+		}
+	}
+	if nationality := q.Get("nationality"); nationality != "" {
+		if f.Nationalities, err = parseSet("nationality", nationality); err != nil {
+			return f, err
+		}
+		if f.Nationalities == nil {
+			f.Nationality = nationality
+		}
+		for i := range f.Nationalities {
+			f.Nationalities[i] = strings.ToUpper(f.Nationalities[i])
+		}
+	}
+
+	if f.AgeMin, err = parseOptionalInt(q, "age_min"); err != nil {
+		return f, err
+	}
+	if f.AgeMax, err = parseOptionalInt(q, "age_max"); err != nil {
+		return f, err
+	}
+	if f.AgeMin != nil && f.AgeMax != nil && *f.AgeMin > *f.AgeMax {
+		return f, badRequest(CodeInvalidQuery, "age_min must not be greater than age_max")
+	}
+	if f.CreatedAfter, err = parseTime(q, "created_after"); err != nil {
+		return f, err
+	}
+	if f.CreatedBefore, err = parseTime(q, "created_before"); err != nil {
+		return f, err
+	}
+	if f.IsNull, err = parseNullable(q, "is_null"); err != nil {
+		return f, err
+	}
+	if f.NotNull, err = parseNullable(q, "not_null"); err != nil {
+		return f, err
+	}
+	if f.Filter = q.Get("filter"); f.Filter != "" {
+		if f.Expr, err = filterexpr.Parse(f.Filter, repository.FilterFields); err != nil {
+			return f, badRequest(CodeInvalidFilter, err.Error())
+		}
+	}
+	if f.IncludeDeleted, err = parseBool(q, "include_deleted"); err != nil {
+		return f, err
+	}
+	if f.Fields, err = parseFields(q); err != nil {
+		return f, err
+	}
+
+	if sort := q.Get("sort"); sort != "" {
+		fields, err := parseSort(sort)
+		if err != nil {
+			return f, err
+		}
+		f.Sort = fields
+	}
+
+	// Результаты полнотекстового поиска по умолчанию упорядочены по релевантности
+	if f.Q != "" && len(f.Sort) == 0 {
+		f.Sort = []models.SortField{{Field: repository.RelevanceField, Desc: true}}
+	}
+
+	sortsByRelevance := slices.ContainsFunc(f.Sort, func(s models.SortField) bool {
+		return s.Field == repository.RelevanceField
+	})
+	if sortsByRelevance && !hasRelevance(f) {
+		return f, badRequest(CodeInvalidQuery, "sorting by relevance requires q or a fuzzy match filter")
+	}
+
+	return f, parsePagination(q, &f)
+}
+
+// filtersFromQueryString разбирает параметры поиска, переданные строкой в теле запроса.
+// Параметры страницы отбрасываются, вместе с фильтрами возвращается нормализованная строка запроса
+func filtersFromQueryString(query string) (models.Filters, string, error) {
+	q, err := url.ParseQuery(query)
+	if err != nil {
+		return models.Filters{}, "", badRequest(CodeInvalidQuery, "query must be a URL query string")
+	}
+	for _, key := range []string{"page", "page_size", "after"} {
+		q.Del(key)
+	}
+	filters, err := parseFilters(q)
+	if err != nil {
+		return models.Filters{}, "", err
+	}
+	filters.Limit, filters.Offset = 0, 0
+	return filters, q.Encode(), nil
+}
+
+// parsePagination разбирает параметры страницы поиска: page_size, after и page.
+// Курсор проверяется на соответствие уже разобранному порядку сортировки f.Sort
+func parsePagination(q url.Values, f *models.Filters) error {
+	f.Limit = defaultPageSize
+	f.Offset = 0
+	f.After = nil
+
+	if ps := q.Get("page_size"); ps != "" {
+		maxPageSize := config.Get().MaxPageSize
+		v, err := strconv.Atoi(ps)
+		if err != nil || v < 1 || v > maxPageSize {
+			return badRequest(CodeInvalidQuery, fmt.Sprintf("page_size must be an integer between 1 and %d", maxPageSize))
+		}
+		f.Limit = v
+	}
+
+	if after := q.Get("after"); after != "" {
+		if slices.ContainsFunc(f.Sort, func(s models.SortField) bool { return s.Field == repository.RelevanceField }) {
+			return badRequest(CodeInvalidQuery, "cursor pagination is not supported when sorting by relevance")
+		}
+		c, err := repository.DecodeCursor(after, f.Sort)
+		if err != nil {
+			return err
+		}
+		f.After = &c
+		return nil
+	}
+
+	page := 1
+	if p := q.Get("page"); p != "" {
+		if v, err := strconv.Atoi(p); err == nil && v > 0 {
+			page = v
+		}
+	}
+	f.Offset = (page - 1) * f.Limit
+
+	return nil
+}
+
+// parseMatchMode разбирает режим сравнения текстового фильтра
+func parseMatchMode(q url.Values, key string) (models.MatchMode, error) {
+	switch mode := models.MatchMode(q.Get(key)); mode {
+	case "":
+		return models.MatchContains, nil
+	case models.MatchExact, models.MatchPrefix, models.MatchContains, models.MatchFuzzy:
+		return mode, nil
+	}
+	return "", badRequest(CodeInvalidQuery, key+" must be one of: exact, prefix, contains, fuzzy")
+}
+
+// hasRelevance сообщает, задан ли фильтр, по которому вычисляется релевантность
+func hasRelevance(f models.Filters) bool {
+	return f.Q != "" ||
+		f.Name != "" && f.NameMatch == models.MatchFuzzy ||
+		f.Surname != "" && f.SurnameMatch == models.MatchFuzzy ||
+		f.Patronymic != "" && f.PatronymicMatch == models.MatchFuzzy
+}
+
+// parseSet разбирает значение параметра key вида "in:a,b,c" в список допустимых значений.
+// Для значения без префикса in: возвращается nil, пустой список - ошибка,
+// иначе фильтр молча пропал бы и под него подошли бы все записи
+func parseSet(key, value string) ([]string, error) {
+	list, ok := strings.CutPrefix(value, "in:")
+	if !ok {
+		return nil, nil
+	}
+
+	set := []string{}
+	for _, v := range strings.Split(list, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			set = append(set, v)
+		}
+	}
+	if len(set) == 0 {
+		return nil, badRequest(CodeInvalidQuery, key+" must list at least one value after in:")
+	}
+	return set, nil
+}
+
+func parseOptionalInt(q url.Values, key string) (*int, error) {
+	value := q.Get(key)
+	if value == "" {
+		return nil, nil
+	}
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, badRequest(CodeInvalidQuery, key+" must be an integer")
+	}
+	return &v, nil
+}
+
+// parseIntParam разбирает необязательный целочисленный параметр в пределах [lo, hi],
+// по умолчанию def
+func parseIntParam(q url.Values, key string, def, lo, hi int) (int, error) {
+	value := q.Get(key)
+	if value == "" {
+		return def, nil
+	}
+	v, err := strconv.Atoi(value)
+	if err != nil || v < lo || v > hi {
+		return 0, badRequest(CodeInvalidQuery, fmt.Sprintf("%s must be an integer between %d and %d", key, lo, hi))
+	}
+	return v, nil
+}
+
+// parseBool разбирает необязательный логический параметр, по умолчанию false
+func parseBool(q url.Values, key string) (bool, error) {
+	value := q.Get(key)
+	if value == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, badRequest(CodeInvalidQuery, key+" must be a boolean")
+	}
+	return b, nil
+}
+
+// parseTime разбирает момент времени в формате RFC 3339 или дату вида 2006-01-02.
+// Дата без смещения означает полночь по UTC
+func parseTime(q url.Values, key string) (time.Time, error) {
+	value := q.Get(key)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, badRequest(CodeInvalidQuery, key+" must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+}
+
+// parseNullable разбирает список необязательных полей для проверки на заполненность
+func parseNullable(q url.Values, key string) ([]string, error) {
+	value := q.Get(key)
+	if value == "" {
+		return nil, nil
+	}
+
+	fields := []string{}
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if !repository.IsNullable(field) {
+			return nil, badRequest(CodeInvalidQuery, fmt.Sprintf("%s: field %q can't be checked for null", key, field))
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// parseSort разбирает параметр сортировки вида "-age,surname": поля перечисляются через запятую
+// в порядке приоритета, минус перед полем означает сортировку по убыванию
+func parseSort(value string) ([]models.SortField, error) {
+	fields := []models.SortField{}
+	seen := map[string]bool{}
+
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		field := models.SortField{Field: strings.TrimPrefix(part, "-"), Desc: strings.HasPrefix(part, "-")}
+
+		if !repository.IsSortable(field.Field) {
+			return nil, badRequest(CodeInvalidQuery, fmt.Sprintf("can't sort by %q", field.Field))
+		}
+		if seen[field.Field] {
+			return nil, badRequest(CodeInvalidQuery, fmt.Sprintf("duplicate sort field %q", field.Field))
+		}
+		seen[field.Field] = true
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+// paginationLinks формирует заголовок Link (RFC 8288) со ссылками на соседние страницы
+// результата поиска, сохраняя остальные параметры запроса. При пагинации по курсору
+// доступны только ссылки на первую и следующую страницы.
+func paginationLinks(r *http.Request, resp models.SearchResponse) string {
+	link := func(rel string, set map[string]string) string {
+		q := r.URL.Query()
+		q.Del("page")
+		q.Del("after")
+		q.Set("page_size", strconv.Itoa(resp.PageSize))
+		for k, v := range set {
+			q.Set(k, v)
+		}
+		return fmt.Sprintf(`<%s?%s>; rel="%s"`, r.URL.Path, q.Encode(), rel)
+	}
+	page := func(n int) map[string]string {
+		return map[string]string{"page": strconv.Itoa(n)}
+	}
+
+	if resp.Page == 0 {
+		links := []string{link("first", page(1))}
+		if resp.HasNext {
+			links = append(links, link("next", map[string]string{"after": resp.NextCursor}))
+		}
+		return strings.Join(links, ", ")
+	}
+
+	lastPage := (resp.Total + resp.PageSize - 1) / resp.PageSize
+	if lastPage < 1 {
+		lastPage = 1
+	}
+
+	links := []string{link("first", page(1))}
+	if resp.Page > 1 {
+		links = append(links, link("prev", page(min(resp.Page-1, lastPage))))
+	}
+	if resp.HasNext {
+		links = append(links, link("next", page(resp.Page+1)))
+	}
+	links = append(links, link("last", page(lastPage)))
+
+	return strings.Join(links, ", ")
+}
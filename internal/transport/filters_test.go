@@ -0,0 +1,193 @@
+package transport
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"people-credentials-api/internal/config"
+	"people-credentials-api/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// -----------------------------
+// Тесты разбора фильтров поиска
+// -----------------------------
+func TestBuildFiltersPagination(t *testing.T) {
+	f, err := buildFiltersFromQuery(httptest.NewRequest("GET", "/api/v1/search?page=3&page_size=10", nil))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 10, f.Limit)
+	assert.Equal(t, 20, f.Offset)
+}
+
+func TestBuildFiltersDefaultPageSize(t *testing.T) {
+	f, err := buildFiltersFromQuery(httptest.NewRequest("GET", "/api/v1/search?page=abc", nil))
+
+	assert.NoError(t, err)
+	assert.Equal(t, defaultPageSize, f.Limit)
+	assert.Equal(t, 0, f.Offset)
+}
+
+func TestBuildFiltersRejectsPageSizeAboveMaximum(t *testing.T) {
+	_, err := buildFiltersFromQuery(httptest.NewRequest("GET", "/api/v1/search?page_size=100000", nil))
+
+	assert.Equal(t, CodeInvalidQuery, problemFromError(err).Code)
+}
+
+func TestPaginationLinks(t *testing.T) {
+	r := httptest.NewRequest("GET", "/api/v1/search?name=ivan&page=2", nil)
+	resp := models.SearchResponse{Total: 45, Page: 2, PageSize: 20, HasNext: true}
+
+	links := paginationLinks(r, resp)
+
+	assert.Equal(t, `</api/v1/search?name=ivan&page=1&page_size=20>; rel="first", `+
+		`</api/v1/search?name=ivan&page=1&page_size=20>; rel="prev", `+
+		`</api/v1/search?name=ivan&page=3&page_size=20>; rel="next", `+
+		`</api/v1/search?name=ivan&page=3&page_size=20>; rel="last"`, links)
+}
+
+func TestPaginationLinksWithCursor(t *testing.T) {
+	r := httptest.NewRequest("GET", "/api/v1/search?after=abc&page_size=5", nil)
+	resp := models.SearchResponse{Total: 45, PageSize: 5, HasNext: true, NextCursor: "def"}
+
+	links := paginationLinks(r, resp)
+
+	assert.Equal(t, `</api/v1/search?page=1&page_size=5>; rel="first", `+
+		`</api/v1/search?after=def&page_size=5>; rel="next"`, links)
+}
+
+func TestBuildFiltersCursorTakesPrecedence(t *testing.T) {
+	f, err := buildFiltersFromQuery(httptest.NewRequest("GET", "/api/v1/search?page=3&after=eyJpZCI6MTB9", nil))
+
+	assert.NoError(t, err)
+	assert.Equal(t, &models.Cursor{ID: 10}, f.After)
+	assert.Equal(t, 0, f.Offset)
+}
+
+func TestParseSort(t *testing.T) {
+	fields, err := parseSort("-age, surname")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []models.SortField{{Field: "age", Desc: true}, {Field: "surname"}}, fields)
+}
+
+func TestParseSortRejectsUnknownAndDuplicateFields(t *testing.T) {
+	for _, sort := range []string{"password", "age,-age", "", "name,"} {
+		_, err := parseSort(sort)
+		assert.Equal(t, CodeInvalidQuery, problemFromError(err).Code, sort)
+	}
+}
+
+func TestBuildFiltersRangesAndSets(t *testing.T) {
+	r := httptest.NewRequest("GET", "/api/v1/search?age_min=18&age_max=30&gender=in:male,female"+
+		"&nationality=in:ru,UA&created_after=2024-01-01&is_null=patronymic,age", nil)
+
+	f, err := buildFiltersFromQuery(r)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 18, *f.AgeMin)
+	assert.Equal(t, 30, *f.AgeMax)
+	assert.Equal(t, []string{"male", "female"}, f.Genders)
+	assert.Empty(t, f.Gender)
+	assert.Equal(t, []string{"RU", "UA"}, f.Nationalities)
+	assert.Equal(t, 2024, f.CreatedAfter.Year())
+	assert.True(t, f.CreatedBefore.IsZero())
+	assert.Equal(t, []string{"patronymic", "age"}, f.IsNull)
+}
+
+func TestBuildFiltersRejectsInvalidRanges(t *testing.T) {
+	for _, query := range []string{
+		"age_min=abc", "age_min=40&age_max=30", "created_before=yesterday", "is_null=name", "gender=in:", "nationality=in:,",
+	} {
+		_, err := buildFiltersFromQuery(httptest.NewRequest("GET", "/api/v1/search?"+query, nil))
+		assert.Equal(t, CodeInvalidQuery, problemFromError(err).Code, query)
+	}
+}
+
+func TestBuildFiltersMatchModes(t *testing.T) {
+	f, err := buildFiltersFromQuery(httptest.NewRequest("GET", "/api/v1/search?name=ivan&name_match=fuzzy&sort=-relevance", nil))
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.MatchFuzzy, f.NameMatch)
+	assert.Equal(t, models.MatchContains, f.SurnameMatch)
+}
+
+func TestBuildFiltersRejectsInvalidMatchModes(t *testing.T) {
+	for _, query := range []string{
+		"name=ivan&name_match=regex",
+		"name=ivan&sort=-relevance",
+		"name=ivan&name_match=fuzzy&sort=-relevance&after=eyJpZCI6MTB9",
+	} {
+		_, err := buildFiltersFromQuery(httptest.NewRequest("GET", "/api/v1/search?"+query, nil))
+		assert.Equal(t, CodeInvalidQuery, problemFromError(err).Code, query)
+	}
+}
+
+func TestBuildFiltersFullTextDefaultsToRelevance(t *testing.T) {
+	f, err := buildFiltersFromQuery(httptest.NewRequest("GET", "/api/v1/search?q=Ivanov+Petr", nil))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Ivanov Petr", f.Q)
+	assert.Equal(t, []models.SortField{{Field: "relevance", Desc: true}}, f.Sort)
+}
+
+func TestBuildFiltersExpression(t *testing.T) {
+	f, err := buildFiltersFromQuery(httptest.NewRequest("GET", "/api/v1/search?filter="+url.QueryEscape("gender:female OR age>30"), nil))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "gender:female OR age>30", f.Filter)
+	assert.NotNil(t, f.Expr)
+
+	_, err = buildFiltersFromQuery(httptest.NewRequest("GET", "/api/v1/search?filter="+url.QueryEscape("salary>30"), nil))
+	problem := problemFromError(err)
+	assert.Equal(t, CodeInvalidFilter, problem.Code)
+	assert.Contains(t, problem.Detail, `unknown field "salary"`)
+}
+
+func TestBuildFiltersIncludeDeletedRequiresAdmin(t *testing.T) {
+	cfg := config.Get()
+	defer func(key string) { cfg.AdminAPIKey = key }(cfg.AdminAPIKey)
+	cfg.AdminAPIKey = "secret"
+
+	f, err := buildFiltersFromQuery(httptest.NewRequest("GET", "/api/v1/search?name=ivan", nil))
+	assert.NoError(t, err)
+	assert.False(t, f.IncludeDeleted)
+
+	r := httptest.NewRequest("GET", "/api/v1/search?include_deleted=true", nil)
+	_, err = buildFiltersFromQuery(r)
+	assert.Equal(t, CodeForbidden, problemFromError(err).Code)
+
+	r.Header.Set(adminKeyHeader, "secret")
+	f, err = buildFiltersFromQuery(r)
+	assert.NoError(t, err)
+	assert.True(t, f.IncludeDeleted)
+
+	_, err = buildFiltersFromQuery(httptest.NewRequest("GET", "/api/v1/search?include_deleted=maybe", nil))
+	assert.Equal(t, CodeInvalidQuery, problemFromError(err).Code)
+}
+
+func TestIsAdminDisabledWithoutKey(t *testing.T) {
+	cfg := config.Get()
+	defer func(key string) { cfg.AdminAPIKey = key }(cfg.AdminAPIKey)
+	cfg.AdminAPIKey = ""
+
+	r := httptest.NewRequest("POST", "/api/v1/persons/1/restore", nil)
+	r.Header.Set(adminKeyHeader, "")
+	assert.False(t, isAdmin(r))
+}
+
+func TestParseIntParam(t *testing.T) {
+	v, err := parseIntParam(url.Values{}, "page_size", 10, 1, 150)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, v)
+
+	v, err = parseIntParam(url.Values{"page_size": {"5"}}, "page_size", 10, 1, 150)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, v)
+
+	for _, value := range []string{"0", "151", "ten"} {
+		_, err := parseIntParam(url.Values{"page_size": {value}}, "page_size", 10, 1, 150)
+		assert.Equal(t, CodeInvalidQuery, problemFromError(err).Code, value)
+	}
+}
@@ -2,34 +2,40 @@ package transport
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"people-credentials-api/internal/enricher"
 	"people-credentials-api/internal/models"
 	"people-credentials-api/internal/repository"
+	"people-credentials-api/internal/validation"
+	"people-credentials-api/pkg/logger"
+	"people-credentials-api/pkg/patch"
 	"strconv"
 )
 
 // AddNewPersonHandler godoc
 // @Summary Create a New Person
 // @Description Enriches provided person details using external APIs and creates a new person record in the database.
+// @Description Persons with equal or similar full names are reported as possible duplicates: depending on the
+// @Description configured policy the request is rejected or the person is created with a list of duplicates.
 // @Tags person
 // @Accept json
 // @Produce json
+// @Produce application/problem+json
+// @Param force query bool false "Create the person without the duplicate check"
 // @Param payload body models.InsertPersonRequest true "Insert Person Request"
-// @Success 201 {string} string "Created"
-// @Failure 400 {object} models.ErrorResponse "Bad Request"
-// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
-// @Router /api/v1/person/create [post]
+// @Success 201 {object} models.CreatePersonResponse "Created"
+// @Failure 400 {object} models.Problem "Bad Request"
+// @Failure 409 {object} models.Problem "Possible Duplicates Found"
+// @Failure 422 {object} models.Problem "Validation Failed"
+// @Failure 500 {object} models.Problem "Internal Server Error"
+// @Router /api/v1/persons [post]
 func AddNewPersonHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		InvalidMethodResponse(w, r)
-		return
-	}
-
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		ErrorResponse(w, http.StatusBadRequest, "Can't read POST body")
+		ErrorResponse(w, r, badRequest(CodeInvalidBody, "Can't read POST body"))
 		return
 	}
 	defer r.Body.Close()
@@ -37,16 +43,36 @@ func AddNewPersonHandler(w http.ResponseWriter, r *http.Request) {
 	var payload models.InsertPersonRequest
 	err = json.Unmarshal(body, &payload)
 	if err != nil {
-		ErrorResponse(w, http.StatusBadRequest, "Can't parse POST body")
+		ErrorResponse(w, r, badRequest(CodeInvalidBody, "Can't parse POST body"))
+		return
+	}
+	if errs := validation.Validate(payload); len(errs) > 0 {
+		ErrorResponse(w, r, validationFailed(errs))
 		return
 	}
-	enrichedPerson, err := enricher.Enrich(payload)
-	err = repository.InsertPerson(enrichedPerson)
+
+	duplicates, err := findDuplicates(r, payload)
 	if err != nil {
-		ErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		ErrorResponse(w, r, err)
 		return
 	}
-	w.WriteHeader(http.StatusCreated)
+
+	enrichedPerson, err := enricher.Enrich(r.Context(), payload)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	person, err := insertPerson(r, enrichedPerson)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	auditDuplicates(r, duplicates)
+	w.Header().Set("Location", fmt.Sprintf("/api/v1/persons/%d", person.ID))
+	w.Header().Set("ETag", etag(person.Version))
+	jsonResponse(w, http.StatusCreated, models.CreatePersonResponse{Person: person, PossibleDuplicates: duplicates})
 }
 
 // EditPersonHandler godoc
@@ -55,32 +81,35 @@ func AddNewPersonHandler(w http.ResponseWriter, r *http.Request) {
 // @Tags person
 // @Accept json
 // @Produce json
-// @Param id query int true "Person ID"
+// @Produce application/problem+json
+// @Param id path int true "Person ID"
+// @Param If-Match header string false "ETag of the person version being edited"
 // @Param payload body models.Person true "Person Data"
-// @Success 200 {string} string "OK"
-// @Failure 400 {object} models.ErrorResponse "Bad Request"
-// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
-// @Router /api/v1/person/edit [put]
+// @Success 200 {object} models.Person "Updated person"
+// @Header 200 {string} ETag "Version of the updated person"
+// @Failure 400 {object} models.Problem "Bad Request"
+// @Failure 404 {object} models.Problem "Person Not Found"
+// @Failure 412 {object} models.Problem "Person Version Conflict"
+// @Failure 422 {object} models.Problem "Validation Failed"
+// @Failure 428 {object} models.Problem "If-Match Required"
+// @Failure 500 {object} models.Problem "Internal Server Error"
+// @Router /api/v1/persons/{id} [put]
 func EditPersonHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPut {
-		InvalidMethodResponse(w, r)
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		ErrorResponse(w, r, badRequest(CodeInvalidID, "Invalid id"))
 		return
 	}
 
-	idStr := r.URL.Query().Get("id")
-	if idStr == "" {
-		ErrorResponse(w, http.StatusBadRequest, "Missing id in query")
-		return
-	}
-	id, err := strconv.Atoi(idStr)
+	version, err := expectedVersion(r)
 	if err != nil {
-		ErrorResponse(w, http.StatusBadRequest, "Invalid id")
+		ErrorResponse(w, r, err)
 		return
 	}
 
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		ErrorResponse(w, http.StatusBadRequest, "Can't read PUT body")
+		ErrorResponse(w, r, badRequest(CodeInvalidBody, "Can't read PUT body"))
 		return
 	}
 	defer r.Body.Close()
@@ -88,150 +117,363 @@ func EditPersonHandler(w http.ResponseWriter, r *http.Request) {
 	var payload models.Person
 	err = json.Unmarshal(body, &payload)
 	if err != nil {
-		ErrorResponse(w, http.StatusBadRequest, "Can't parse PUT body")
+		ErrorResponse(w, r, badRequest(CodeInvalidBody, "Can't parse PUT body"))
+		return
+	}
+	if errs := validation.Validate(payload); len(errs) > 0 {
+		ErrorResponse(w, r, validationFailed(errs))
 		return
 	}
 
-	err = repository.UpdatePerson(id, payload)
+	updated, err := repository.UpdatePerson(r.Context(), id, payload, version)
 	if err != nil {
-		ErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		ErrorResponse(w, r, err)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
+	PersonResponse(w, http.StatusOK, updated)
+}
+
+// PatchPersonHandler godoc
+// @Summary Partially Update a Person
+// @Description Applies a JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902) to an existing person and updates only the affected fields.
+// @Description Fields removed by the patch are set to null.
+// @Tags person
+// @Accept application/merge-patch+json
+// @Accept application/json-patch+json
+// @Produce json
+// @Produce application/problem+json
+// @Param id path int true "Person ID"
+// @Param If-Match header string false "ETag of the person version being edited"
+// @Param payload body object true "Merge patch document or list of JSON Patch operations"
+// @Success 200 {object} models.Person "Updated person"
+// @Header 200 {string} ETag "Version of the updated person"
+// @Failure 400 {object} models.Problem "Bad Request"
+// @Failure 404 {object} models.Problem "Person Not Found"
+// @Failure 412 {object} models.Problem "Person Version Conflict"
+// @Failure 415 {object} models.Problem "Unsupported Media Type"
+// @Failure 422 {object} models.Problem "Validation Failed"
+// @Failure 428 {object} models.Problem "If-Match Required"
+// @Failure 500 {object} models.Problem "Internal Server Error"
+// @Router /api/v1/persons/{id} [patch]
+func PatchPersonHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		ErrorResponse(w, r, badRequest(CodeInvalidID, "Invalid id"))
+		return
+	}
+
+	version, err := expectedVersion(r)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		ErrorResponse(w, r, badRequest(CodeInvalidBody, "Can't read PATCH body"))
+		return
+	}
+	defer r.Body.Close()
+
+	current, err := repository.GetPersonByID(r.Context(), id, false)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+	// Патч применяется к прочитанной версии, поэтому без If-Match она же
+	// используется для защиты от параллельного изменения записи
+	if version == 0 {
+		version = current.Version
+	}
+	if version != current.Version {
+		ErrorResponse(w, r, repository.ErrVersionConflict)
+		return
+	}
+
+	doc, err := personDocument(current)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	var patched map[string]any
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/json-patch+json":
+		patched, err = patch.ApplyJSONPatch(doc, body)
+	case "", "application/json", "application/merge-patch+json":
+		patched, err = patch.MergePatch(doc, body)
+	default:
+		ErrorResponse(w, r, &requestError{
+			status: http.StatusUnsupportedMediaType,
+			code:   CodeUnsupportedMediaType,
+			detail: "Unsupported Content-Type " + mediaType,
+		})
+		return
+	}
+	if err != nil {
+		ErrorResponse(w, r, badRequest(CodeInvalidPatch, "Can't apply patch: "+err.Error()))
+		return
+	}
+
+	changes, err := personChanges(doc, patched)
+	if err != nil {
+		ErrorResponse(w, r, badRequest(CodeInvalidPatch, "Invalid patch: "+err.Error()))
+		return
+	}
+	result, err := personFromDocument(patched)
+	if err != nil {
+		ErrorResponse(w, r, badRequest(CodeInvalidPatch, "Invalid patch: "+err.Error()))
+		return
+	}
+	if errs := validation.Validate(result); len(errs) > 0 {
+		ErrorResponse(w, r, validationFailed(errs))
+		return
+	}
+
+	updated, err := repository.PatchPerson(r.Context(), id, changes, version)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	PersonResponse(w, http.StatusOK, updated)
 }
 
 // DeletePersonHandler godoc
 // @Summary Delete a Person
-// @Description Deletes a person record identified by the provided ID.
+// @Description Marks a person record identified by the provided ID as deleted.
+// @Description Deleted persons can be restored until they are purged after the retention period.
 // @Tags person
 // @Accept json
 // @Produce json
-// @Param id query int true "Person ID"
+// @Produce application/problem+json
+// @Param id path int true "Person ID"
+// @Param If-Match header string false "ETag of the person version being deleted"
 // @Success 200 {string} string "OK"
-// @Failure 400 {object} models.ErrorResponse "Bad Request"
-// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
-// @Router /api/v1/person/delete [delete]
+// @Failure 400 {object} models.Problem "Bad Request"
+// @Failure 404 {object} models.Problem "Person Not Found"
+// @Failure 412 {object} models.Problem "Person Version Conflict"
+// @Failure 428 {object} models.Problem "If-Match Required"
+// @Failure 500 {object} models.Problem "Internal Server Error"
+// @Router /api/v1/persons/{id} [delete]
 func DeletePersonHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodDelete {
-		InvalidMethodResponse(w, r)
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		ErrorResponse(w, r, badRequest(CodeInvalidID, "Invalid id"))
 		return
 	}
 
-	idStr := r.URL.Query().Get("id")
-	if idStr == "" {
-		ErrorResponse(w, http.StatusBadRequest, "Missing id in query")
+	version, err := expectedVersion(r)
+	if err != nil {
+		ErrorResponse(w, r, err)
 		return
 	}
-	id, err := strconv.Atoi(idStr)
+
+	err = repository.DeletePersonByID(r.Context(), id, version)
 	if err != nil {
-		ErrorResponse(w, http.StatusBadRequest, "Invalid id")
+		ErrorResponse(w, r, err)
 		return
 	}
 
-	err = repository.DeletePersonByID(id)
+	w.WriteHeader(http.StatusOK)
+}
+
+// RestorePersonHandler godoc
+// @Summary Restore a Deleted Person
+// @Description Restores a deleted person record identified by the provided ID. Requires administrator key.
+// @Tags person
+// @Produce json
+// @Produce application/problem+json
+// @Param id path int true "Person ID"
+// @Param X-Admin-Key header string true "Administrator key"
+// @Success 200 {object} models.Person "Restored person"
+// @Header 200 {string} ETag "Version of the restored person"
+// @Failure 400 {object} models.Problem "Bad Request"
+// @Failure 403 {object} models.Problem "Forbidden"
+// @Failure 404 {object} models.Problem "Person Not Found"
+// @Failure 409 {object} models.Problem "Person Is Not Deleted"
+// @Failure 500 {object} models.Problem "Internal Server Error"
+// @Router /api/v1/persons/{id}/restore [post]
+func RestorePersonHandler(w http.ResponseWriter, r *http.Request) {
+	if err := requireAdmin(r); err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+	id, err := strconv.Atoi(r.PathValue("id"))
 	if err != nil {
-		ErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		ErrorResponse(w, r, badRequest(CodeInvalidID, "Invalid id"))
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
+	person, err := repository.RestorePerson(r.Context(), id)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	PersonResponse(w, http.StatusOK, person)
+}
+
+// GetPersonHandler godoc
+// @Summary Get a Person
+// @Description Retrieves a single person record identified by the provided ID.
+// @Tags person
+// @Produce json
+// @Produce application/problem+json
+// @Param id path int true "Person ID"
+// @Param fields query string false "Comma-separated fields to return, e.g. id,name,surname"
+// @Param include_deleted query bool false "Return the person even if it is deleted, requires X-Admin-Key"
+// @Param X-Admin-Key header string false "Administrator key"
+// @Success 200 {object} models.Person "Person"
+// @Header 200 {string} ETag "Version of the person"
+// @Failure 400 {object} models.Problem "Bad Request"
+// @Failure 403 {object} models.Problem "Forbidden"
+// @Failure 404 {object} models.Problem "Person Not Found"
+// @Failure 500 {object} models.Problem "Internal Server Error"
+// @Router /api/v1/persons/{id} [get]
+func GetPersonHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		ErrorResponse(w, r, badRequest(CodeInvalidID, "Invalid id"))
+		return
+	}
+	q := r.URL.Query()
+	fields, err := parseFields(q)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+	includeDeleted, err := parseBool(q, "include_deleted")
+	if err == nil && includeDeleted {
+		err = requireAdmin(r)
+	}
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	person, err := repository.GetPersonByID(r.Context(), id, includeDeleted, fields...)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+	if len(fields) == 0 {
+		PersonResponse(w, http.StatusOK, person)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(person.Version))
+	if err := json.NewEncoder(w).Encode(projectPerson(person, fields)); err != nil {
+		logger.Error("Failed to encode response: " + err.Error())
+	}
 }
 
 // SearchPersonHandler godoc
 // @Summary Search for Persons
-// @Description Retrieves a list of persons based on provided filter criteria with pagination support.
+// @Description Retrieves a list of persons based on provided filter criteria with page-number or cursor pagination.
 // @Tags person
 // @Accept json
 // @Produce json
+// @Produce application/problem+json
 // @Param id query int false "Filter by Person ID"
+// @Param q query string false "Full-text search across name, surname and patronymic, results are ranked by relevance"
 // @Param name query string false "Filter by first name"
+// @Param name_match query string false "Match mode for name: exact, prefix, contains (default) or fuzzy"
 // @Param surname query string false "Filter by surname"
+// @Param surname_match query string false "Match mode for surname: exact, prefix, contains (default) or fuzzy"
 // @Param patronymic query string false "Filter by patronymic"
+// @Param patronymic_match query string false "Match mode for patronymic: exact, prefix, contains (default) or fuzzy"
 // @Param age query int false "Filter by age"
-// @Param gender query string false "Filter by gender"
-// @Param nationality query string false "Filter by nationality"
+// @Param gender query string false "Filter by gender, or by a set of genders as in:male,female"
+// @Param nationality query string false "Filter by nationality, or by a set of country codes as in:RU,UA,KZ"
+// @Param age_min query int false "Minimum age, inclusive"
+// @Param age_max query int false "Maximum age, inclusive"
+// @Param created_after query string false "Created after the moment (RFC 3339 with offset, or YYYY-MM-DD in UTC)"
+// @Param created_before query string false "Created before the moment (RFC 3339 with offset, or YYYY-MM-DD in UTC)"
+// @Param is_null query string false "Comma-separated optional fields that must be empty (patronymic, age, gender, nationality)"
+// @Param not_null query string false "Comma-separated optional fields that must be filled (patronymic, age, gender, nationality)"
+// @Param filter query string false "Boolean filter expression, e.g. (gender:female AND age>=30) OR nationality:KZ"
+// @Param include_deleted query bool false "Include deleted persons, requires X-Admin-Key"
+// @Param X-Admin-Key header string false "Administrator key"
+// @Param fields query string false "Comma-separated fields to return for each person, e.g. id,name,surname"
 // @Param page query int false "Page number for pagination"
+// @Param page_size query int false "Number of persons per page (default 20, limited by server maximum)"
+// @Param sort query string false "Comma-separated sort fields, prefix with - for descending order (e.g. -age,surname). -relevance ranks fuzzy matches by similarity"
+// @Param after query string false "Opaque cursor from next_cursor of the previous page, takes precedence over page"
 // @Success 200 {object} models.SearchResponse "Search results"
-// @Failure 400 {object} models.ErrorResponse "Bad Request"
-// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Header 200 {string} Link "Links to the first, previous, next and last pages"
+// @Failure 400 {object} models.Problem "Bad Request"
+// @Failure 403 {object} models.Problem "Forbidden"
+// @Failure 500 {object} models.Problem "Internal Server Error"
 // @Router /api/v1/search [get]
+// @Router /api/v1/persons [get]
 func SearchPersonHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		InvalidMethodResponse(w, r)
+	filters, err := buildFiltersFromQuery(r)
+	if err != nil {
+		ErrorResponse(w, r, err)
 		return
 	}
 
-	filters := buildFiltersFromQuery(r)
+	searchResponse(w, r, filters)
+}
 
-	people, err := repository.GetPeople(filters)
+// searchResponse выполняет поиск и отвечает страницей результатов
+// с общим количеством записей, курсором и ссылками на соседние страницы
+func searchResponse(w http.ResponseWriter, r *http.Request, filters models.Filters) {
+	// Лишняя запись показывает, есть ли следующая страница
+	query := filters
+	query.Limit++
+	people, err := repository.GetPeople(r.Context(), query)
 	if err != nil {
-		ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch people: "+err.Error())
+		ErrorResponse(w, r, err)
 		return
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(people); err != nil {
-		ErrorResponse(w, http.StatusInternalServerError, "Failed to encode response")
+	total, err := repository.CountPeople(r.Context(), filters)
+	if err != nil {
+		ErrorResponse(w, r, err)
 		return
 	}
-}
 
-func InvalidMethodResponse(w http.ResponseWriter, r *http.Request) {
-	ErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed "+r.Method)
-}
-
-func ErrorResponse(w http.ResponseWriter, statusCode int, errorMessage string) {
-	w.WriteHeader(statusCode)
-	w.Header().Set("Content-Type", "application/json")
-	resp := models.ErrorResponse{Error: errorMessage}
-	if err := json.NewEncoder(w).Encode(resp); err != nil {
-		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+	resp := models.SearchResponse{
+		Persons:  people,
+		Total:    total,
+		PageSize: filters.Limit,
 	}
-}
-
-func buildFiltersFromQuery(r *http.Request) models.Filters {
-	q := r.URL.Query()
-
-	const defaultLimit = 20
-	f := models.Filters{
-		Limit:  defaultLimit,
-		Offset: 0,
-	}
-
-	if id := q.Get("id"); id != "" {
-		if v, err := strconv.Atoi(id); err == nil {
-			f.ID = v
-		}
-	}
-	if name := q.Get("name"); name != "" {
-		f.Name = name
-	}
-	if surname := q.Get("surname"); surname != "" {
-		f.Surname = surname
+	if len(people) > filters.Limit {
+		resp.Persons = people[:filters.Limit]
+		resp.HasNext = true
+		resp.NextCursor = repository.EncodeCursor(resp.Persons[len(resp.Persons)-1], filters.Sort)
 	}
-	if patronymic := q.Get("patronymic"); patronymic != "" {
-		f.Patronymic = patronymic
+	if filters.After == nil {
+		resp.Page = filters.Offset/filters.Limit + 1
 	}
-	if age := q.Get("age"); age != "" {
-		if v, err := strconv.Atoi(age); err == nil {
-			f.Age = v
-		}
-	}
-	if gender := q.Get("gender"); gender != "" {
-		f.Gender = gender
+
+	ids := make([]int, len(resp.Persons))
+	for i, p := range resp.Persons {
+		ids[i] = p.ID
 	}
-	if nationality := q.Get("nationality"); nationality != "" {
-		f.Nationality = nationality
+	auditTargets(r, ids)
+
+	var body any = resp
+	if len(filters.Fields) > 0 {
+		body = projectSearchResponse(resp, filters.Fields)
 	}
 
-	page := 1
-	if p := q.Get("page"); p != "" {
-		if v, err := strconv.Atoi(p); err == nil && v > 0 {
-			page = v
-		}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Link", paginationLinks(r, resp))
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		logger.Error("Failed to encode response: " + err.Error())
 	}
-	f.Offset = (page - 1) * defaultLimit
+}
 
-	return f
+func PersonResponse(w http.ResponseWriter, statusCode int, person models.Person) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(person.Version))
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(person); err != nil {
+		logger.Error("Failed to encode response: " + err.Error())
+	}
 }
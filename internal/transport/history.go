@@ -0,0 +1,118 @@
+package transport
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"people-credentials-api/internal/models"
+	"people-credentials-api/internal/repository"
+	"people-credentials-api/internal/validation"
+	"strconv"
+)
+
+// PersonHistoryHandler godoc
+// @Summary Get Person History
+// @Description Lists every change of a person record in order: creation, edits, deletion, restoring and purge.
+// @Description Each entry holds snapshots of the record before and after the change and the X-Actor of the request.
+// @Description Snapshots are erased when the person is purged.
+// @Description History of a deleted person requires X-Admin-Key, like the person itself.
+// @Tags person
+// @Produce json
+// @Produce application/problem+json
+// @Param id path int true "Person ID"
+// @Param X-Admin-Key header string false "Administrator key"
+// @Success 200 {array} models.PersonHistoryEntry "History entries"
+// @Failure 400 {object} models.Problem "Bad Request"
+// @Failure 403 {object} models.Problem "Person Is Deleted and X-Admin-Key Is Missing or Invalid"
+// @Failure 404 {object} models.Problem "Person Not Found"
+// @Failure 500 {object} models.Problem "Internal Server Error"
+// @Router /api/v1/persons/{id}/history [get]
+func PersonHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		ErrorResponse(w, r, badRequest(CodeInvalidID, "Invalid id"))
+		return
+	}
+
+	// Снимки удалённой записи содержат её данные, поэтому, как и сама запись, доступны только
+	// администратору. После окончательного удаления записи нет, а снимки стёрты
+	person, err := repository.GetPersonByID(r.Context(), id, true, "deleted_at")
+	if err != nil && !errors.Is(err, repository.ErrPersonNotFound) {
+		ErrorResponse(w, r, err)
+		return
+	}
+	if err == nil && person.DeletedAt != nil {
+		if err := requireAdmin(r); err != nil {
+			ErrorResponse(w, r, err)
+			return
+		}
+	}
+
+	entries, err := repository.GetPersonHistory(r.Context(), id)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, entries)
+}
+
+// RevertPersonHandler godoc
+// @Summary Revert a Person
+// @Description Restores the fields of a person to the state of a previous version from its history.
+// @Description The revert is stored as a new version of the person.
+// @Tags person
+// @Accept json
+// @Produce json
+// @Produce application/problem+json
+// @Param id path int true "Person ID"
+// @Param If-Match header string false "ETag of the person version being reverted"
+// @Param payload body models.RevertRequest true "Version to revert to"
+// @Success 200 {object} models.Person "Reverted person"
+// @Header 200 {string} ETag "Version of the reverted person"
+// @Failure 400 {object} models.Problem "Bad Request"
+// @Failure 404 {object} models.Problem "Person or Version Not Found"
+// @Failure 412 {object} models.Problem "Person Version Conflict"
+// @Failure 422 {object} models.Problem "Validation Failed"
+// @Failure 428 {object} models.Problem "If-Match Required"
+// @Failure 500 {object} models.Problem "Internal Server Error"
+// @Router /api/v1/persons/{id}/revert [post]
+func RevertPersonHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		ErrorResponse(w, r, badRequest(CodeInvalidID, "Invalid id"))
+		return
+	}
+
+	version, err := expectedVersion(r)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		ErrorResponse(w, r, badRequest(CodeInvalidBody, "Can't read request body"))
+		return
+	}
+	defer r.Body.Close()
+
+	var payload models.RevertRequest
+	if err := json.Unmarshal(body, &payload); err != nil {
+		ErrorResponse(w, r, badRequest(CodeInvalidBody, "Can't parse request body"))
+		return
+	}
+	if errs := validation.Validate(payload); len(errs) > 0 {
+		ErrorResponse(w, r, validationFailed(errs))
+		return
+	}
+
+	reverted, err := repository.RevertPerson(r.Context(), id, payload.Version, version)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	PersonResponse(w, http.StatusOK, reverted)
+}
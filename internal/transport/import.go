@@ -0,0 +1,348 @@
+package transport
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"people-credentials-api/internal/config"
+	"people-credentials-api/internal/enricher"
+	"people-credentials-api/internal/models"
+	"people-credentials-api/internal/repository"
+	"people-credentials-api/internal/validation"
+	"people-credentials-api/pkg/logger"
+	"strings"
+)
+
+const (
+	// importChunkSize - количество строк, которые обогащаются и вставляются одним COPY
+	importChunkSize = 500
+	// maxImportLine - максимальная длина строки NDJSON в байтах
+	maxImportLine = 64 * 1024
+)
+
+// Статусы строк отчёта об импорте
+const (
+	importCreated      = "created"
+	importInvalid      = "invalid"
+	importFailed       = "failed"
+	importNotAttempted = "not_attempted"
+)
+
+// importRow - строка импорта: разобранная запись или ошибка её разбора
+type importRow struct {
+	payload models.InsertPersonRequest
+	err     *models.FieldError
+}
+
+// ImportPersonsHandler godoc
+// @Summary Import Persons
+// @Description Creates persons in bulk from a JSON array, NDJSON or CSV with a header row (name, surname, patronymic).
+// @Description Every row is validated and enriched, valid rows are inserted in chunks. The report lists the outcome of every row.
+// @Description If the import is interrupted, the report is marked as interrupted: created rows are kept, not attempted rows can be sent again.
+// @Tags person
+// @Accept json
+// @Accept application/x-ndjson
+// @Accept text/csv
+// @Produce json
+// @Produce application/problem+json
+// @Param payload body []models.InsertPersonRequest true "Persons to import"
+// @Success 200 {object} models.ImportReport "Per-row import report"
+// @Failure 400 {object} models.Problem "Bad Request"
+// @Failure 413 {object} models.Problem "Too Many Rows or Body Too Large"
+// @Failure 415 {object} models.Problem "Unsupported Media Type"
+// @Failure 500 {object} models.Problem "Internal Server Error"
+// @Router /api/v1/persons/import [post]
+func ImportPersonsHandler(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, int64(config.Get().ImportMaxBytes))
+	defer r.Body.Close()
+
+	rows, err := parseImport(r)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	report := importRows(r.Context(), rows)
+	ids := make([]int, 0, report.Created)
+	for _, row := range report.Rows {
+		if row.Status == importCreated {
+			ids = append(ids, row.ID)
+		}
+	}
+	auditTargets(r, ids)
+	if report.Interrupted {
+		logger.Warn(fmt.Sprintf("Import interrupted: %d of %d persons created, %d rows not attempted",
+			report.Created, report.Total, report.NotAttempted))
+	} else {
+		logger.Info(fmt.Sprintf("Imported %d of %d persons", report.Created, report.Total))
+	}
+	jsonResponse(w, http.StatusOK, report)
+}
+
+// parseImport разбирает тело запроса импорта в формате, заданном Content-Type
+func parseImport(r *http.Request) ([]importRow, error) {
+	maxRows := config.Get().ImportMaxRows
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	var rows []importRow
+	var err error
+	switch mediaType {
+	case "", "application/json":
+		rows, err = parseJSONImport(r.Body, maxRows)
+	case "application/x-ndjson":
+		rows, err = parseNDJSONImport(r.Body, maxRows)
+	case "text/csv":
+		rows, err = parseCSVImport(r.Body, maxRows)
+	default:
+		return nil, &requestError{
+			status: http.StatusUnsupportedMediaType,
+			code:   CodeUnsupportedMediaType,
+			detail: "Unsupported Content-Type " + mediaType,
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, badRequest(CodeInvalidBody, "Import contains no rows")
+	}
+	return rows, nil
+}
+
+func parseJSONImport(body io.Reader, maxRows int) ([]importRow, error) {
+	dec := json.NewDecoder(body)
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, readFailed(err, "JSON import must be an array of persons")
+	}
+	if tok != json.Delim('[') {
+		return nil, badRequest(CodeInvalidBody, "JSON import must be an array of persons")
+	}
+
+	rows := []importRow{}
+	for dec.More() {
+		if len(rows) == maxRows {
+			return nil, tooManyRows(maxRows)
+		}
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, readFailed(err, "Can't parse JSON import: "+err.Error())
+		}
+		rows = append(rows, jsonImportRow(raw))
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, readFailed(err, "Can't parse JSON import: "+err.Error())
+	}
+	return rows, nil
+}
+
+func parseNDJSONImport(body io.Reader, maxRows int) ([]importRow, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 4096), maxImportLine)
+
+	rows := []importRow{}
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if len(rows) == maxRows {
+			return nil, tooManyRows(maxRows)
+		}
+		rows = append(rows, jsonImportRow([]byte(line)))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, readFailed(err, "Can't read NDJSON import: "+err.Error())
+	}
+	return rows, nil
+}
+
+func jsonImportRow(raw []byte) importRow {
+	var row importRow
+	if err := json.Unmarshal(raw, &row.payload); err != nil {
+		row.err = &models.FieldError{Code: CodeMalformedRow, Message: "row is not a valid person object"}
+	}
+	return row
+}
+
+// parseCSVImport разбирает CSV с заголовком. Порядок колонок произвольный,
+// колонки name и surname обязательны
+func parseCSVImport(body io.Reader, maxRows int) ([]importRow, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, readFailed(err, "CSV import must start with a header row")
+	}
+	columns := map[string]int{}
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(column))
+		if column != "name" && column != "surname" && column != "patronymic" {
+			return nil, badRequest(CodeInvalidBody, fmt.Sprintf("Unknown CSV column %q", column))
+		}
+		columns[column] = i
+	}
+	for _, required := range []string{"name", "surname"} {
+		if _, ok := columns[required]; !ok {
+			return nil, badRequest(CodeInvalidBody, fmt.Sprintf("CSV header must contain column %q", required))
+		}
+	}
+
+	rows := []importRow{}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if len(rows) == maxRows {
+			return nil, tooManyRows(maxRows)
+		}
+
+		var parseErr *csv.ParseError
+		switch {
+		case errors.As(err, &parseErr):
+			rows = append(rows, importRow{err: &models.FieldError{Code: CodeMalformedRow, Message: parseErr.Err.Error()}})
+			continue
+		case err != nil:
+			return nil, readFailed(err, "Can't read CSV import: "+err.Error())
+		case len(record) != len(header):
+			rows = append(rows, importRow{err: &models.FieldError{
+				Code:    CodeMalformedRow,
+				Message: fmt.Sprintf("row has %d fields, header has %d", len(record), len(header)),
+			}})
+			continue
+		}
+
+		var row importRow
+		row.payload.Name = record[columns["name"]]
+		row.payload.Surname = record[columns["surname"]]
+		if i, ok := columns["patronymic"]; ok {
+			row.payload.Patronymic = record[i]
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// readFailed превращает ошибку чтения или разбора тела импорта в ответ: превышение
+// PEOPLE_CREDENTIALS_IMPORT_MAX_BYTES - 413, остальные ошибки - 400 с описанием detail
+func readFailed(err error, detail string) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return &requestError{
+			status: http.StatusRequestEntityTooLarge,
+			code:   CodeBodyTooLarge,
+			detail: fmt.Sprintf("Import body is limited to %d bytes", tooLarge.Limit),
+		}
+	}
+	return badRequest(CodeInvalidBody, detail)
+}
+
+func tooManyRows(maxRows int) error {
+	return &requestError{
+		status: http.StatusRequestEntityTooLarge,
+		code:   CodeTooManyRows,
+		detail: fmt.Sprintf("Import is limited to %d rows", maxRows),
+	}
+}
+
+// importRows проверяет строки, обогащает и вставляет корректные частями по importChunkSize.
+// Ошибки отдельных строк попадают в отчёт. Прерывание запроса останавливает импорт, но отчёт
+// всё равно возвращается: вставленные части сохраняются вместе с ID, а необработанные строки
+// помечаются как not_attempted, чтобы клиент мог отправить повторно только их
+func importRows(ctx context.Context, rows []importRow) models.ImportReport {
+	report := models.ImportReport{Total: len(rows), Rows: make([]models.ImportRowResult, len(rows))}
+
+	valid := []int{}
+	for i, row := range rows {
+		result := &report.Rows[i]
+		result.Row = i + 1
+		switch {
+		case row.err != nil:
+			result.Status = importInvalid
+			result.Errors = []models.FieldError{*row.err}
+		default:
+			if errs := validation.Validate(row.payload); len(errs) > 0 {
+				result.Status = importInvalid
+				result.Errors = errs
+				continue
+			}
+			valid = append(valid, i)
+		}
+	}
+
+	for start := 0; start < len(valid); start += importChunkSize {
+		chunk := valid[start:min(start+importChunkSize, len(valid))]
+		if err := importChunk(ctx, rows, chunk, report.Rows); err != nil {
+			logger.Warn(fmt.Sprintf("Import stopped before row %d: %s", chunk[0]+1, err.Error()))
+			report.Interrupted = true
+			break
+		}
+	}
+
+	for i := range report.Rows {
+		switch report.Rows[i].Status {
+		case importCreated:
+			report.Created++
+		case "":
+			report.Rows[i].Status = importNotAttempted
+			report.NotAttempted++
+		default:
+			report.Failed++
+		}
+	}
+	return report
+}
+
+// importChunk обогащает и вставляет строки с индексами chunk, записывая результат в results.
+// Ошибка возвращается, только если запрос прерван до сохранения части: её строки остаются без результата
+func importChunk(ctx context.Context, rows []importRow, chunk []int, results []models.ImportRowResult) error {
+	payloads := make([]models.InsertPersonRequest, len(chunk))
+	for i, idx := range chunk {
+		payloads[i] = rows[idx].payload
+	}
+
+	people, errs := enricher.EnrichBatch(ctx, payloads)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var toInsert []models.Person
+	var inserted []int
+	for i, idx := range chunk {
+		if errs[i] != nil {
+			results[idx].Status = importFailed
+			results[idx].Errors = []models.FieldError{{Code: CodeEnrichmentFailed, Message: "failed to enrich person data"}}
+			continue
+		}
+		toInsert = append(toInsert, people[i])
+		inserted = append(inserted, idx)
+	}
+
+	// Часть, которую успели сохранить, попадает в отчёт с ID, даже если запрос уже прерван
+	ids, err := repository.InsertPeople(ctx, toInsert)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		logger.Error(fmt.Sprintf("Failed to insert import chunk of %d persons: %s", len(toInsert), err.Error()))
+	}
+	for i, idx := range inserted {
+		if err != nil {
+			results[idx].Status = importFailed
+			results[idx].Errors = []models.FieldError{{Code: CodeInsertFailed, Message: "failed to save person"}}
+			continue
+		}
+		results[idx].Status = importCreated
+		results[idx].ID = ids[i]
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"people-credentials-api/internal/models"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ---------------------
+// Тесты разбора импорта
+// ---------------------
+func TestParseJSONImport(t *testing.T) {
+	rows, err := parseJSONImport(strings.NewReader(`[{"name":"Ivan","surname":"Petrov"}, {"name":5}]`), 10)
+
+	assert.NoError(t, err)
+	assert.Len(t, rows, 2)
+	assert.Equal(t, models.InsertPersonRequest{Name: "Ivan", Surname: "Petrov"}, rows[0].payload)
+	assert.Nil(t, rows[0].err)
+	assert.Equal(t, CodeMalformedRow, rows[1].err.Code)
+}
+
+func TestParseJSONImportErrors(t *testing.T) {
+	for _, body := range []string{`{"name":"Ivan"}`, `[{"name":`, `[{"name":"Ivan"} {"name":"Petr"}]`} {
+		_, err := parseJSONImport(strings.NewReader(body), 10)
+		assert.Equal(t, CodeInvalidBody, problemFromError(err).Code, body)
+	}
+
+	_, err := parseJSONImport(strings.NewReader(`[{}, {}, {}]`), 2)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, problemFromError(err).Status)
+}
+
+func TestParseNDJSONImport(t *testing.T) {
+	rows, err := parseNDJSONImport(strings.NewReader("{\"name\":\"Ivan\",\"surname\":\"Petrov\"}\n\nnot json\n"), 10)
+
+	assert.NoError(t, err)
+	assert.Len(t, rows, 2)
+	assert.Equal(t, "Ivan", rows[0].payload.Name)
+	assert.Equal(t, CodeMalformedRow, rows[1].err.Code)
+}
+
+func TestParseCSVImport(t *testing.T) {
+	rows, err := parseCSVImport(strings.NewReader("Surname,name,patronymic\nPetrov,Ivan,Sergeevich\nSidorov,Petr\n"), 10)
+
+	assert.NoError(t, err)
+	assert.Len(t, rows, 2)
+	assert.Equal(t, models.InsertPersonRequest{Name: "Ivan", Surname: "Petrov", Patronymic: "Sergeevich"}, rows[0].payload)
+	assert.Equal(t, CodeMalformedRow, rows[1].err.Code)
+}
+
+func TestParseCSVImportErrors(t *testing.T) {
+	for _, body := range []string{"", "name,age\nIvan,30\n", "name\nIvan\n"} {
+		_, err := parseCSVImport(strings.NewReader(body), 10)
+		assert.Equal(t, CodeInvalidBody, problemFromError(err).Code, body)
+	}
+}
+
+func TestImportRowsReportsInvalidRows(t *testing.T) {
+	report := importRows(context.Background(), []importRow{
+		{payload: models.InsertPersonRequest{Name: "Ivan"}},
+		{err: &models.FieldError{Code: CodeMalformedRow, Message: "row is not a valid person object"}},
+	})
+
+	assert.False(t, report.Interrupted)
+	assert.Equal(t, 2, report.Total)
+	assert.Equal(t, 0, report.Created)
+	assert.Equal(t, 2, report.Failed)
+	assert.Equal(t, importInvalid, report.Rows[0].Status)
+	assert.Equal(t, "surname", report.Rows[0].Errors[0].Field)
+	assert.Equal(t, 2, report.Rows[1].Row)
+	assert.Equal(t, CodeMalformedRow, report.Rows[1].Errors[0].Code)
+}
+
+func TestImportRowsReturnsReportWhenInterrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	report := importRows(ctx, []importRow{
+		{payload: models.InsertPersonRequest{Name: "Ivan", Surname: "Ivanov"}},
+		{payload: models.InsertPersonRequest{Name: "Ivan"}},
+	})
+
+	assert.True(t, report.Interrupted)
+	assert.Equal(t, 1, report.NotAttempted)
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, importNotAttempted, report.Rows[0].Status)
+	assert.Equal(t, 1, report.Rows[0].Row)
+	assert.Equal(t, importInvalid, report.Rows[1].Status)
+}
+
+func TestImportRejectsTooLargeBody(t *testing.T) {
+	for contentType, body := range map[string]string{
+		"application/json":     `[{"name":"` + strings.Repeat("a", 100) + `","surname":"Ivanov"}]`,
+		"application/x-ndjson": `{"name":"` + strings.Repeat("a", 100) + `","surname":"Ivanov"}`,
+		"text/csv":             "name,surname\n" + strings.Repeat("a", 100) + ",Ivanov\n",
+	} {
+		rec := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/persons/import", strings.NewReader(body))
+		r.Header.Set("Content-Type", contentType)
+		r.Body = http.MaxBytesReader(rec, r.Body, 32)
+
+		_, err := parseImport(r)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, problemFromError(err).Status, contentType)
+		assert.Equal(t, CodeBodyTooLarge, problemFromError(err).Code, contentType)
+	}
+}
@@ -0,0 +1,94 @@
+package transport
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"people-credentials-api/internal/models"
+	"reflect"
+)
+
+// personDocument представляет запись в виде JSON-документа, к которому применяются патчи
+func personDocument(p models.Person) (map[string]any, error) {
+	raw, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]any
+	err = json.Unmarshal(raw, &doc)
+	return doc, err
+}
+
+// personFromDocument собирает запись из JSON-документа после применения патча
+func personFromDocument(doc map[string]any) (models.Person, error) {
+	var p models.Person
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return p, err
+	}
+	err = json.Unmarshal(raw, &p)
+	return p, err
+}
+
+// personChanges сравнивает документ до и после применения патча и возвращает
+// изменённые поля с проверенными типами. Удалённое поле соответствует значению nil,
+// пустая строка и нулевой возраст в необязательных полях тоже записываются как NULL.
+func personChanges(before, after map[string]any) (map[string]any, error) {
+	changed := map[string]any{}
+	for field, value := range after {
+		if old, ok := before[field]; !ok || !reflect.DeepEqual(old, value) {
+			changed[field] = value
+		}
+	}
+	for field := range before {
+		if _, ok := after[field]; !ok {
+			changed[field] = nil
+		}
+	}
+
+	changes := make(map[string]any, len(changed))
+	for field, value := range changed {
+		switch field {
+		case "id", "version", "created_at", "updated_at":
+			return nil, fmt.Errorf("field %q can't be changed", field)
+		case "name", "surname":
+			s, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("field %q must be a string", field)
+			}
+			changes[field] = s
+		case "patronymic", "gender", "nationality":
+			if value == nil {
+				changes[field] = nil
+				continue
+			}
+			s, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("field %q must be a string or null", field)
+			}
+			if s == "" {
+				changes[field] = nil
+				continue
+			}
+			changes[field] = s
+		case "age":
+			if value == nil {
+				changes[field] = nil
+				continue
+			}
+			n, ok := value.(float64)
+			if !ok || n != math.Trunc(n) {
+				return nil, fmt.Errorf("field %q must be an integer or null", field)
+			}
+			if n == 0 {
+				changes[field] = nil
+				continue
+			}
+			changes[field] = int(n)
+		default:
+			return nil, fmt.Errorf("unknown field %q", field)
+		}
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,84 @@
+package transport
+
+import (
+	"fmt"
+	"net/url"
+	"people-credentials-api/internal/models"
+	"people-credentials-api/internal/repository"
+	"slices"
+	"strings"
+)
+
+// parseFields разбирает список полей ответа вида "id,name,surname".
+// Пустой параметр означает, что возвращаются все поля
+func parseFields(q url.Values) ([]string, error) {
+	value := q.Get("fields")
+	if value == "" {
+		return nil, nil
+	}
+
+	fields := []string{}
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if !repository.IsProjectable(field) {
+			return nil, badRequest(CodeInvalidQuery, fmt.Sprintf("fields: unknown field %q", field))
+		}
+		if slices.Contains(fields, field) {
+			return nil, badRequest(CodeInvalidQuery, fmt.Sprintf("fields: duplicate field %q", field))
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// projectPerson оставляет в JSON-представлении записи только запрошенные поля
+func projectPerson(p models.Person, fields []string) map[string]any {
+	projected := make(map[string]any, len(fields))
+	for _, field := range fields {
+		projected[field] = fieldValue(p, field)
+	}
+	return projected
+}
+
+// fieldValue возвращает значение поля записи по его JSON-имени
+func fieldValue(p models.Person, field string) any {
+	switch field {
+	case "id":
+		return p.ID
+	case "name":
+		return p.Name
+	case "surname":
+		return p.Surname
+	case "patronymic":
+		return p.Patronymic
+	case "age":
+		return p.Age
+	case "gender":
+		return p.Gender
+	case "nationality":
+		return p.Nationality
+	case "version":
+		return p.Version
+	case "created_at":
+		return p.CreatedAt
+	case "updated_at":
+		return p.UpdatedAt
+	case "deleted_at":
+		return p.DeletedAt
+	}
+	return nil
+}
+
+// projectedSearchResponse - ответ поиска, в котором записи содержат только запрошенные поля
+type projectedSearchResponse struct {
+	models.SearchResponse
+	Persons []map[string]any `json:"persons"`
+}
+
+func projectSearchResponse(resp models.SearchResponse, fields []string) projectedSearchResponse {
+	persons := make([]map[string]any, len(resp.Persons))
+	for i, p := range resp.Persons {
+		persons[i] = projectPerson(p, fields)
+	}
+	return projectedSearchResponse{SearchResponse: resp, Persons: persons}
+}
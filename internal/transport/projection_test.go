@@ -0,0 +1,42 @@
+package transport
+
+import (
+	"encoding/json"
+	"net/url"
+	"people-credentials-api/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// -------------------------
+// Тесты выбора полей ответа
+// -------------------------
+func TestParseFields(t *testing.T) {
+	fields, err := parseFields(url.Values{"fields": {"id, name,surname"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"id", "name", "surname"}, fields)
+
+	fields, err = parseFields(url.Values{})
+	assert.NoError(t, err)
+	assert.Nil(t, fields)
+
+	for _, value := range []string{"id,salary", "name,name", "id,"} {
+		_, err := parseFields(url.Values{"fields": {value}})
+		assert.Equal(t, CodeInvalidQuery, problemFromError(err).Code, value)
+	}
+}
+
+func TestProjectSearchResponse(t *testing.T) {
+	resp := models.SearchResponse{
+		Persons:  []models.Person{{ID: 1, Name: "Ivan", Surname: "Petrov", Age: 30, Version: 2}},
+		Total:    1,
+		PageSize: 20,
+		Page:     1,
+	}
+
+	data, err := json.Marshal(projectSearchResponse(resp, []string{"name", "age"}))
+
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"persons":[{"name":"Ivan","age":30}],"total":1,"page":1,"page_size":20,"has_next":false}`, string(data))
+}
@@ -0,0 +1,291 @@
+package transport
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"people-credentials-api/internal/config"
+	"people-credentials-api/internal/models"
+	"people-credentials-api/internal/repository"
+	"people-credentials-api/internal/validation"
+	"people-credentials-api/pkg/logger"
+	"strconv"
+)
+
+// ListSavedSearchesHandler godoc
+// @Summary List Saved Searches
+// @Description Retrieves a page of saved searches of all owners or of the given owner, ordered by ID.
+// @Description Searches whose stored filters no longer parse are returned with the reason in the invalid field.
+// @Tags saved-search
+// @Produce json
+// @Produce application/problem+json
+// @Param owner query string false "Return only searches of this owner"
+// @Param page query int false "Page number for pagination"
+// @Param page_size query int false "Number of saved searches per page (default 20, limited by server maximum)"
+// @Success 200 {object} models.SavedSearchPage "Saved searches"
+// @Failure 400 {object} models.Problem "Bad Request"
+// @Failure 500 {object} models.Problem "Internal Server Error"
+// @Router /api/v1/saved-searches [get]
+func ListSavedSearchesHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	page, err := parseIntParam(q, "page", 1, 1, 1<<20)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+	pageSize, err := parseIntParam(q, "page_size", defaultPageSize, 1, config.Get().MaxPageSize)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	searches, hasNext, err := repository.ListSavedSearches(r.Context(), q.Get("owner"), pageSize, (page-1)*pageSize)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, models.SavedSearchPage{
+		SavedSearches: searches,
+		Page:          page,
+		PageSize:      pageSize,
+		HasNext:       hasNext,
+	})
+}
+
+// CreateSavedSearchHandler godoc
+// @Summary Create a Saved Search
+// @Description Stores search parameters under a name. The caller identified by X-Actor becomes the owner.
+// @Description X-Actor is not authenticated, so ownership is advisory: it prevents accidental changes, not malicious ones.
+// @Tags saved-search
+// @Accept json
+// @Produce json
+// @Produce application/problem+json
+// @Param X-Actor header string true "Owner of the saved search"
+// @Param payload body models.SavedSearchRequest true "Saved Search Request"
+// @Success 201 {object} models.SavedSearch "Created"
+// @Failure 400 {object} models.Problem "Bad Request"
+// @Failure 422 {object} models.Problem "Validation Failed"
+// @Failure 500 {object} models.Problem "Internal Server Error"
+// @Router /api/v1/saved-searches [post]
+func CreateSavedSearchHandler(w http.ResponseWriter, r *http.Request) {
+	owner, err := actorFromRequest(r)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	search, err := savedSearchFromBody(r)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+	search.Owner = owner
+
+	created, err := repository.InsertSavedSearch(r.Context(), search)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/api/v1/saved-searches/%d", created.ID))
+	jsonResponse(w, http.StatusCreated, created)
+}
+
+// GetSavedSearchHandler godoc
+// @Summary Get a Saved Search
+// @Description Retrieves a single saved search identified by the provided ID.
+// @Tags saved-search
+// @Produce json
+// @Produce application/problem+json
+// @Param id path int true "Saved Search ID"
+// @Success 200 {object} models.SavedSearch "Saved search"
+// @Failure 400 {object} models.Problem "Bad Request"
+// @Failure 404 {object} models.Problem "Saved Search Not Found"
+// @Failure 500 {object} models.Problem "Internal Server Error"
+// @Router /api/v1/saved-searches/{id} [get]
+func GetSavedSearchHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		ErrorResponse(w, r, badRequest(CodeInvalidID, "Invalid id"))
+		return
+	}
+
+	search, err := repository.GetSavedSearch(r.Context(), id)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, search)
+}
+
+// UpdateSavedSearchHandler godoc
+// @Summary Update a Saved Search
+// @Description Replaces the name and search parameters of a saved search. Only the owner can update it.
+// @Description Ownership is advisory, as X-Actor is not authenticated.
+// @Tags saved-search
+// @Accept json
+// @Produce json
+// @Produce application/problem+json
+// @Param id path int true "Saved Search ID"
+// @Param X-Actor header string true "Owner of the saved search"
+// @Param payload body models.SavedSearchRequest true "Saved Search Request"
+// @Success 200 {object} models.SavedSearch "Updated saved search"
+// @Failure 400 {object} models.Problem "Bad Request"
+// @Failure 403 {object} models.Problem "Not the Owner"
+// @Failure 404 {object} models.Problem "Saved Search Not Found"
+// @Failure 422 {object} models.Problem "Validation Failed"
+// @Failure 500 {object} models.Problem "Internal Server Error"
+// @Router /api/v1/saved-searches/{id} [put]
+func UpdateSavedSearchHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		ErrorResponse(w, r, badRequest(CodeInvalidID, "Invalid id"))
+		return
+	}
+
+	owner, err := actorFromRequest(r)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	search, err := savedSearchFromBody(r)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	updated, err := repository.UpdateSavedSearch(r.Context(), id, owner, search)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, updated)
+}
+
+// DeleteSavedSearchHandler godoc
+// @Summary Delete a Saved Search
+// @Description Deletes a saved search. Only the owner can delete it.
+// @Description Ownership is advisory, as X-Actor is not authenticated.
+// @Tags saved-search
+// @Produce application/problem+json
+// @Param id path int true "Saved Search ID"
+// @Param X-Actor header string true "Owner of the saved search"
+// @Success 200 "OK"
+// @Failure 400 {object} models.Problem "Bad Request"
+// @Failure 403 {object} models.Problem "Not the Owner"
+// @Failure 404 {object} models.Problem "Saved Search Not Found"
+// @Failure 500 {object} models.Problem "Internal Server Error"
+// @Router /api/v1/saved-searches/{id} [delete]
+func DeleteSavedSearchHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		ErrorResponse(w, r, badRequest(CodeInvalidID, "Invalid id"))
+		return
+	}
+
+	owner, err := actorFromRequest(r)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	if err := repository.DeleteSavedSearch(r.Context(), id, owner); err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// SavedSearchResultsHandler godoc
+// @Summary Run a Saved Search
+// @Description Executes a saved search and returns a page of matching persons, like GET /api/v1/search.
+// @Tags saved-search
+// @Produce json
+// @Produce application/problem+json
+// @Param id path int true "Saved Search ID"
+// @Param page query int false "Page number for pagination"
+// @Param page_size query int false "Number of persons per page (default 20, limited by server maximum)"
+// @Param after query string false "Opaque cursor from next_cursor of the previous page, takes precedence over page"
+// @Success 200 {object} models.SearchResponse "Search results"
+// @Header 200 {string} Link "Links to the first, previous, next and last pages"
+// @Failure 400 {object} models.Problem "Bad Request"
+// @Failure 404 {object} models.Problem "Saved Search Not Found"
+// @Failure 422 {object} models.Problem "Saved Search Filters Are No Longer Valid"
+// @Failure 500 {object} models.Problem "Internal Server Error"
+// @Router /api/v1/saved-searches/{id}/results [get]
+func SavedSearchResultsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		ErrorResponse(w, r, badRequest(CodeInvalidID, "Invalid id"))
+		return
+	}
+
+	search, err := repository.GetSavedSearch(r.Context(), id)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+	// Без неразбираемого выражения поиск вернул бы больше записей, чем было сохранено
+	if search.Invalid != "" {
+		ErrorResponse(w, r, &requestError{
+			status: http.StatusUnprocessableEntity,
+			code:   CodeInvalidSavedSearch,
+			detail: "Saved search can't be run: " + search.Invalid,
+		})
+		return
+	}
+
+	filters := search.Filters
+	if err := authorizeFilters(r, filters); err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+	if err := parsePagination(r.URL.Query(), &filters); err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	searchResponse(w, r, filters)
+}
+
+// savedSearchFromBody читает и проверяет тело запроса создания или изменения сохранённого поиска.
+// Параметры поиска разбираются так же, как в GET /api/v1/search, параметры страницы отбрасываются
+func savedSearchFromBody(r *http.Request) (models.SavedSearch, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return models.SavedSearch{}, badRequest(CodeInvalidBody, "Can't read request body")
+	}
+	defer r.Body.Close()
+
+	var payload models.SavedSearchRequest
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return models.SavedSearch{}, badRequest(CodeInvalidBody, "Can't parse request body")
+	}
+	if errs := validation.Validate(payload); len(errs) > 0 {
+		return models.SavedSearch{}, validationFailed(errs)
+	}
+
+	filters, query, err := filtersFromQueryString(payload.Query)
+	if err != nil {
+		return models.SavedSearch{}, err
+	}
+	if err := authorizeFilters(r, filters); err != nil {
+		return models.SavedSearch{}, err
+	}
+
+	return models.SavedSearch{Name: payload.Name, Query: query, Filters: filters}, nil
+}
+
+func jsonResponse(w http.ResponseWriter, statusCode int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.Error("Failed to encode response: " + err.Error())
+	}
+}
@@ -0,0 +1,94 @@
+package transport
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"people-credentials-api/internal/models"
+	"people-credentials-api/internal/repository"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// -------------------------
+// Тесты сохранённых поисков
+// -------------------------
+func TestSavedSearchFromBody(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/saved-searches",
+		strings.NewReader(`{"name":"Women over 30","query":"gender=female&age_min=30&sort=-age&page=3&page_size=50"}`))
+
+	s, err := savedSearchFromBody(r)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Women over 30", s.Name)
+	assert.Equal(t, "age_min=30&gender=female&sort=-age", s.Query)
+	assert.Equal(t, "female", s.Filters.Gender)
+	assert.Equal(t, []models.SortField{{Field: "age", Desc: true}}, s.Filters.Sort)
+}
+
+func TestSavedSearchFromBodyErrors(t *testing.T) {
+	for body, code := range map[string]string{
+		`not json`:                             CodeInvalidBody,
+		`{"query":"gender=female"}`:            CodeValidationFailed,
+		`{"name":"x","query":"sort=salary"}`:   CodeInvalidQuery,
+		`{"name":"x","query":"filter=age%3E"}`: CodeInvalidFilter,
+	} {
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/saved-searches", strings.NewReader(body))
+
+		_, err := savedSearchFromBody(r)
+		assert.Equal(t, code, problemFromError(err).Code, body)
+	}
+}
+
+func TestCreateSavedSearchRequiresActor(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newRouter().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/saved-searches", strings.NewReader(`{"name":"x"}`)))
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"code":"actor_required"`)
+}
+
+func TestListSavedSearchesRejectsInvalidPage(t *testing.T) {
+	for _, query := range []string{"page=0", "page_size=abc", "page_size=100000"} {
+		rec := httptest.NewRecorder()
+		newRouter().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/saved-searches?"+query, nil))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+		assert.Contains(t, rec.Body.String(), `"code":"invalid_query"`, query)
+	}
+}
+
+func TestActorFromRequest(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(actorHeader, " analyst@example.com ")
+	actor, err := actorFromRequest(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "analyst@example.com", actor)
+
+	for _, value := range []string{"", "bad actor", strings.Repeat("a", 129)} {
+		r.Header.Set(actorHeader, value)
+		_, err := actorFromRequest(r)
+		assert.Equal(t, CodeActorRequired, problemFromError(err).Code, value)
+	}
+}
+
+func TestWithActorPassesActorToContext(t *testing.T) {
+	var actor string
+	handler := withActor(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actor = repository.ActorFromContext(r.Context())
+	}))
+
+	r := httptest.NewRequest(http.MethodDelete, "/api/v1/persons/1", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	assert.Empty(t, actor)
+
+	r.Header.Set(actorHeader, "analyst@example.com")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	assert.Equal(t, "analyst@example.com", actor)
+
+	rec := httptest.NewRecorder()
+	r.Header.Set(actorHeader, "bad actor")
+	handler.ServeHTTP(rec, r)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -1,20 +1,79 @@
 package transport
 
 import (
+	"context"
 	"net/http"
 	"people-credentials-api/internal/config"
+	"people-credentials-api/internal/purge"
 	"people-credentials-api/internal/repository"
 	"people-credentials-api/pkg/logger"
 )
 
 func Run() {
-	logger.InitializeLoggers(config.Get().LogLevel, "")
+	cfg := config.Get()
+	logger.InitializeLoggers(cfg.LogLevel, "")
 	repository.Connect()
 
-	http.HandleFunc("/api/v1/search", SearchPersonHandler)
-	http.HandleFunc("/api/v1/person/create", AddNewPersonHandler)
-	http.HandleFunc("/api/v1/person/edit", EditPersonHandler)
-	http.HandleFunc("/api/v1/person/delete", DeletePersonHandler)
+	go purge.Run(context.Background(), cfg.PurgeInterval, cfg.DeleteRetention)
 
-	logger.Fatal(http.ListenAndServe(":"+config.Get().ServerPort, nil).Error())
+	handler := withTimeouts(cfg, newRouter())
+	if cfg.AuditEnabled {
+		handler = withAudit(cfg.AuditHashChain, handler)
+	}
+	handler = withRequestID(withActor(handler))
+
+	logger.Fatal(http.ListenAndServe(":"+cfg.ServerPort, handler).Error())
+}
+
+// withTimeouts ограничивает время обработки запросов. Выгрузка и импорт обрабатывают
+// много записей за раз, поэтому для них действуют отдельные, более длинные таймауты
+func withTimeouts(cfg *config.Config, router http.Handler) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/", withTimeout(cfg.RequestTimeout, router))
+	mux.Handle("/api/v1/persons/export", withTimeout(cfg.ExportTimeout, router))
+	mux.Handle("/api/v1/persons/import", withTimeout(cfg.ImportTimeout, router))
+	return mux
+}
+
+// newRouter регистрирует маршруты API на отдельном ServeMux. Каждый маршрут помечен
+// действием, под которым запрос попадает в журнал аудита
+func newRouter() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /api/v1/search", audited("person.search", SearchPersonHandler))
+	mux.HandleFunc("GET /api/v1/persons", audited("person.search", SearchPersonHandler))
+	mux.HandleFunc("POST /api/v1/persons", audited("person.create", AddNewPersonHandler))
+	mux.HandleFunc("GET /api/v1/persons/export", audited("person.export", ExportPersonsHandler))
+	mux.HandleFunc("POST /api/v1/persons/import", audited("person.import", ImportPersonsHandler))
+	mux.HandleFunc("POST /api/v1/persons/bulk-delete", audited("person.bulk_delete", BulkDeleteHandler))
+	mux.HandleFunc("POST /api/v1/persons/bulk-update", audited("person.bulk_update", BulkUpdateHandler))
+	mux.HandleFunc("GET /api/v1/persons/duplicates", audited("person.duplicates", DuplicatesReportHandler))
+	mux.HandleFunc("GET /api/v1/persons/{id}", audited("person.get", GetPersonHandler))
+	mux.HandleFunc("PUT /api/v1/persons/{id}", audited("person.update", EditPersonHandler))
+	mux.HandleFunc("PATCH /api/v1/persons/{id}", audited("person.patch", PatchPersonHandler))
+	mux.HandleFunc("DELETE /api/v1/persons/{id}", audited("person.delete", DeletePersonHandler))
+	mux.HandleFunc("POST /api/v1/persons/{id}/restore", audited("person.restore", RestorePersonHandler))
+	mux.HandleFunc("GET /api/v1/persons/{id}/history", audited("person.history", PersonHistoryHandler))
+	mux.HandleFunc("POST /api/v1/persons/{id}/revert", audited("person.revert", RevertPersonHandler))
+
+	mux.HandleFunc("GET /api/v1/stats", audited("stats.summary", StatsSummaryHandler))
+	mux.HandleFunc("GET /api/v1/stats/age", audited("stats.age", AgeHistogramHandler))
+	mux.HandleFunc("GET /api/v1/stats/{field}", audited("stats.group", GroupStatsHandler))
+
+	mux.HandleFunc("GET /api/v1/saved-searches", audited("saved_search.list", ListSavedSearchesHandler))
+	mux.HandleFunc("POST /api/v1/saved-searches", audited("saved_search.create", CreateSavedSearchHandler))
+	mux.HandleFunc("GET /api/v1/saved-searches/{id}", audited("saved_search.get", GetSavedSearchHandler))
+	mux.HandleFunc("PUT /api/v1/saved-searches/{id}", audited("saved_search.update", UpdateSavedSearchHandler))
+	mux.HandleFunc("DELETE /api/v1/saved-searches/{id}", audited("saved_search.delete", DeleteSavedSearchHandler))
+	mux.HandleFunc("GET /api/v1/saved-searches/{id}/results", audited("saved_search.results", SavedSearchResultsHandler))
+
+	mux.HandleFunc("GET /api/v1/audit", audited("audit.list", ListAuditHandler))
+	mux.HandleFunc("GET /api/v1/audit/verify", audited("audit.verify", VerifyAuditHandler))
+
+	// Устаревшие маршруты, оставленные для обратной совместимости
+	mux.HandleFunc("POST /api/v1/person/create", audited("person.create", deprecated("/api/v1/persons", AddNewPersonHandler)))
+	mux.HandleFunc("PUT /api/v1/person/edit", audited("person.update", deprecated("/api/v1/persons/{id}", idFromQuery(EditPersonHandler))))
+	mux.HandleFunc("DELETE /api/v1/person/delete", audited("person.delete", deprecated("/api/v1/persons/{id}", idFromQuery(DeletePersonHandler))))
+
+	return mux
 }
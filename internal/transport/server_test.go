@@ -0,0 +1,60 @@
+package transport
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// -------------------
+// Тесты маршрутизации
+// -------------------
+func TestLegacyRouteIsDeprecated(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newRouter().ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/api/v1/person/edit", nil))
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "true", rec.Header().Get("Deprecation"))
+	assert.Contains(t, rec.Header().Get("Link"), `rel="successor-version"`)
+}
+
+func TestResourceRouteIsNotDeprecated(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newRouter().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/persons/abc", nil))
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Empty(t, rec.Header().Get("Deprecation"))
+}
+
+func TestRequestIDIsPropagated(t *testing.T) {
+	rec := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/persons/abc", nil)
+	r.Header.Set("X-Request-ID", "client-id-1")
+
+	withRequestID(newRouter()).ServeHTTP(rec, r)
+
+	assert.Equal(t, "client-id-1", rec.Header().Get("X-Request-ID"))
+	assert.Contains(t, rec.Body.String(), `"request_id":"client-id-1"`)
+}
+
+func TestRouteRejectsWrongMethod(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newRouter().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/persons/1", nil))
+
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestRevertRequiresVersion(t *testing.T) {
+	for body, code := range map[string]int{
+		`{"version":0}`: http.StatusUnprocessableEntity,
+		`{"version":`:   http.StatusBadRequest,
+	} {
+		rec := httptest.NewRecorder()
+		newRouter().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/persons/1/revert", strings.NewReader(body)))
+
+		assert.Equal(t, code, rec.Code, body)
+	}
+}
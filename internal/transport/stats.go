@@ -0,0 +1,127 @@
+package transport
+
+import (
+	"fmt"
+	"net/http"
+	"people-credentials-api/internal/repository"
+)
+
+const (
+	defaultAgeBucketSize = 10
+	maxAgeBucketSize     = 150
+	maxStatsGroups       = 250
+)
+
+// StatsSummaryHandler godoc
+// @Summary Persons Statistics Summary
+// @Description Counts persons matching the search filters and computes average, minimum and maximum age.
+// @Tags stats
+// @Produce json
+// @Produce application/problem+json
+// @Param q query string false "Full-text search across name, surname and patronymic"
+// @Param gender query string false "Filter by gender, or by a set of genders as in:male,female"
+// @Param nationality query string false "Filter by nationality, or by a set of country codes as in:RU,UA,KZ"
+// @Param age_min query int false "Minimum age, inclusive"
+// @Param age_max query int false "Maximum age, inclusive"
+// @Param filter query string false "Boolean filter expression, e.g. (gender:female AND age>=30) OR nationality:KZ"
+// @Success 200 {object} models.StatsSummary "Summary"
+// @Failure 400 {object} models.Problem "Bad Request"
+// @Failure 500 {object} models.Problem "Internal Server Error"
+// @Router /api/v1/stats [get]
+func StatsSummaryHandler(w http.ResponseWriter, r *http.Request) {
+	filters, err := buildFiltersFromQuery(r)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	summary, err := repository.GetStatsSummary(r.Context(), filters)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, summary)
+}
+
+// GroupStatsHandler godoc
+// @Summary Persons Count by Field
+// @Description Counts persons matching the search filters grouped by gender or nationality, largest groups first.
+// @Description Persons without a value are counted under the "unknown" key.
+// @Tags stats
+// @Produce json
+// @Produce application/problem+json
+// @Param field path string true "Grouping field: gender or nationality"
+// @Param limit query int false "Maximum number of groups to return"
+// @Param gender query string false "Filter by gender, or by a set of genders as in:male,female"
+// @Param nationality query string false "Filter by nationality, or by a set of country codes as in:RU,UA,KZ"
+// @Param age_min query int false "Minimum age, inclusive"
+// @Param age_max query int false "Maximum age, inclusive"
+// @Param filter query string false "Boolean filter expression, e.g. (gender:female AND age>=30) OR nationality:KZ"
+// @Success 200 {object} models.GroupStats "Grouped counts"
+// @Failure 400 {object} models.Problem "Bad Request"
+// @Failure 500 {object} models.Problem "Internal Server Error"
+// @Router /api/v1/stats/{field} [get]
+func GroupStatsHandler(w http.ResponseWriter, r *http.Request) {
+	field := r.PathValue("field")
+	if !repository.IsGroupable(field) {
+		ErrorResponse(w, r, badRequest(CodeInvalidQuery, fmt.Sprintf("can't group by %q", field)))
+		return
+	}
+
+	q := r.URL.Query()
+	limit, err := parseIntParam(q, "limit", 0, 1, maxStatsGroups)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+	filters, err := buildFiltersFromQuery(r)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	stats, err := repository.GetGroupStats(r.Context(), field, filters, limit)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, stats)
+}
+
+// AgeHistogramHandler godoc
+// @Summary Persons Age Histogram
+// @Description Distributes persons matching the search filters into consecutive age ranges.
+// @Tags stats
+// @Produce json
+// @Produce application/problem+json
+// @Param bucket_size query int false "Range length in years (default 10)"
+// @Param gender query string false "Filter by gender, or by a set of genders as in:male,female"
+// @Param nationality query string false "Filter by nationality, or by a set of country codes as in:RU,UA,KZ"
+// @Param filter query string false "Boolean filter expression, e.g. (gender:female AND age>=30) OR nationality:KZ"
+// @Success 200 {object} models.AgeHistogram "Age histogram"
+// @Failure 400 {object} models.Problem "Bad Request"
+// @Failure 500 {object} models.Problem "Internal Server Error"
+// @Router /api/v1/stats/age [get]
+func AgeHistogramHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	bucketSize, err := parseIntParam(q, "bucket_size", defaultAgeBucketSize, 1, maxAgeBucketSize)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+	filters, err := buildFiltersFromQuery(r)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	histogram, err := repository.GetAgeHistogram(r.Context(), filters, bucketSize)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, histogram)
+}
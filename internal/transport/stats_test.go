@@ -0,0 +1,26 @@
+package transport
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ---------------------------
+// Тесты параметров статистики
+// ---------------------------
+func TestStatsRejectsInvalidRequests(t *testing.T) {
+	for _, target := range []string{
+		"/api/v1/stats/name",
+		"/api/v1/stats/gender?limit=0",
+		"/api/v1/stats/age?bucket_size=0",
+		"/api/v1/stats?filter=salary>1",
+	} {
+		rec := httptest.NewRecorder()
+		newRouter().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code, target)
+	}
+}
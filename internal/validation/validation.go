@@ -0,0 +1,165 @@
+package validation
+
+import (
+	"fmt"
+	"people-credentials-api/internal/models"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Коды ошибок валидации, возвращаемые клиенту в поле code
+const (
+	CodeRequired      = "required"
+	CodeTooShort      = "too_short"
+	CodeTooLong       = "too_long"
+	CodeTooSmall      = "too_small"
+	CodeTooLarge      = "too_large"
+	CodeInvalidLength = "invalid_length"
+	CodeNotAllowed    = "not_allowed"
+	CodeInvalidFormat = "invalid_format"
+)
+
+// Validate проверяет структуру по правилам из тегов `validate` и возвращает
+// список ошибок по полям. Пустой список означает, что структура корректна.
+//
+// Поддерживаемые правила:
+//
+//	required   - значение не должно быть нулевым, строка - состоять только из пробелов
+//	min=N      - минимальная длина строки в символах или минимальное число
+//	max=N      - максимальная длина строки в символах или максимальное число
+//	len=N      - точная длина строки в символах
+//	oneof=a b  - значение должно быть одним из перечисленных
+//	alpha      - только буквы, пробелы, дефисы и апострофы
+//	upper      - только заглавные латинские буквы
+//
+// Все правила, кроме required, не применяются к нулевым значениям,
+// поэтому необязательные поля могут отсутствовать.
+func Validate(v any) []models.FieldError {
+	errs := []models.FieldError{}
+
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		tag := field.Tag.Get("validate")
+		if tag == "" {
+			continue
+		}
+
+		name := jsonName(field)
+		value := rv.Field(i)
+		for _, rule := range strings.Split(tag, ",") {
+			if fe, ok := check(name, value, rule); !ok {
+				errs = append(errs, fe)
+				break
+			}
+		}
+	}
+
+	return errs
+}
+
+// check применяет одно правило к значению поля
+func check(field string, value reflect.Value, rule string) (models.FieldError, bool) {
+	name, param, _ := strings.Cut(rule, "=")
+
+	if name == "required" {
+		if value.IsZero() || value.Kind() == reflect.String && strings.TrimSpace(value.String()) == "" {
+			return fieldError(field, CodeRequired, "is required"), false
+		}
+		return models.FieldError{}, true
+	}
+	if value.IsZero() {
+		return models.FieldError{}, true
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		return checkString(field, value.String(), name, param)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return checkInt(field, value.Int(), name, param)
+	}
+	panic(fmt.Sprintf("validation: unsupported kind %s for field %s", value.Kind(), field))
+}
+
+func checkString(field, s, rule, param string) (models.FieldError, bool) {
+	length := utf8.RuneCountInString(s)
+
+	switch rule {
+	case "min":
+		if n := mustAtoi(param); length < n {
+			return fieldError(field, CodeTooShort, fmt.Sprintf("must be at least %d characters long", n)), false
+		}
+	case "max":
+		if n := mustAtoi(param); length > n {
+			return fieldError(field, CodeTooLong, fmt.Sprintf("must be at most %d characters long", n)), false
+		}
+	case "len":
+		if n := mustAtoi(param); length != n {
+			return fieldError(field, CodeInvalidLength, fmt.Sprintf("must be exactly %d characters long", n)), false
+		}
+	case "oneof":
+		allowed := strings.Fields(param)
+		for _, a := range allowed {
+			if s == a {
+				return models.FieldError{}, true
+			}
+		}
+		return fieldError(field, CodeNotAllowed, "must be one of: "+strings.Join(allowed, ", ")), false
+	case "alpha":
+		for _, r := range s {
+			if !unicode.IsLetter(r) && r != ' ' && r != '-' && r != '\'' {
+				return fieldError(field, CodeInvalidFormat, "must contain only letters, spaces, hyphens and apostrophes"), false
+			}
+		}
+	case "upper":
+		for _, r := range s {
+			if r < 'A' || r > 'Z' {
+				return fieldError(field, CodeInvalidFormat, "must contain only uppercase latin letters"), false
+			}
+		}
+	default:
+		panic("validation: unknown rule " + rule)
+	}
+	return models.FieldError{}, true
+}
+
+func checkInt(field string, v int64, rule, param string) (models.FieldError, bool) {
+	switch rule {
+	case "min":
+		if n := mustAtoi(param); v < int64(n) {
+			return fieldError(field, CodeTooSmall, fmt.Sprintf("must be at least %d", n)), false
+		}
+	case "max":
+		if n := mustAtoi(param); v > int64(n) {
+			return fieldError(field, CodeTooLarge, fmt.Sprintf("must be at most %d", n)), false
+		}
+	default:
+		panic("validation: unknown rule " + rule + " for integer field")
+	}
+	return models.FieldError{}, true
+}
+
+func fieldError(field, code, message string) models.FieldError {
+	return models.FieldError{Field: field, Code: code, Message: field + " " + message}
+}
+
+// jsonName возвращает имя поля в JSON, под которым его видит клиент
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
+
+func mustAtoi(s string) int {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		panic("validation: invalid rule parameter " + s)
+	}
+	return n
+}
@@ -0,0 +1,54 @@
+package validation
+
+import (
+	"people-credentials-api/internal/models"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateValidPerson(t *testing.T) {
+	p := models.Person{Name: "Анна-Мария", Surname: "O'Brien", Age: 30, Gender: "female", Nationality: "IE"}
+	assert.Empty(t, Validate(p))
+}
+
+func TestValidateOptionalFieldsMayBeEmpty(t *testing.T) {
+	assert.Empty(t, Validate(models.InsertPersonRequest{Name: "ivan", Surname: "ivanov"}))
+}
+
+func TestValidateReportsEveryInvalidField(t *testing.T) {
+	p := models.Person{
+		Name:        "",
+		Surname:     strings.Repeat("a", 65),
+		Patronymic:  "R2D2",
+		Age:         -1,
+		Gender:      "unknown",
+		Nationality: "rus",
+	}
+
+	errs := Validate(&p)
+
+	assert.Equal(t, []models.FieldError{
+		{Field: "name", Code: CodeRequired, Message: "name is required"},
+		{Field: "surname", Code: CodeTooLong, Message: "surname must be at most 64 characters long"},
+		{Field: "patronymic", Code: CodeInvalidFormat, Message: "patronymic must contain only letters, spaces, hyphens and apostrophes"},
+		{Field: "age", Code: CodeTooSmall, Message: "age must be at least 0"},
+		{Field: "gender", Code: CodeNotAllowed, Message: "gender must be one of: male, female"},
+		{Field: "nationality", Code: CodeInvalidLength, Message: "nationality must be exactly 2 characters long"},
+	}, errs)
+}
+
+func TestValidateCountsCharactersNotBytes(t *testing.T) {
+	p := models.InsertPersonRequest{Name: strings.Repeat("я", 64), Surname: "ivanov"}
+	assert.Empty(t, Validate(p))
+}
+
+func TestValidateRequiredRejectsBlankString(t *testing.T) {
+	errs := Validate(models.InsertPersonRequest{Name: "   ", Surname: "\t"})
+
+	assert.Equal(t, []models.FieldError{
+		{Field: "name", Code: CodeRequired, Message: "name is required"},
+		{Field: "surname", Code: CodeRequired, Message: "surname is required"},
+	}, errs)
+}
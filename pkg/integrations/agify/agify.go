@@ -1,17 +1,28 @@
 package agify
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 )
 
-func GetAge(name string) (int, error) {
-	url := fmt.Sprintf("https://api.agify.io/?name=%s", name)
-	resp, err := http.Get(url)
+// ErrNoPrediction возвращается, если Agify не может предсказать возраст по имени
+var ErrNoPrediction = errors.New("no prediction for name")
+
+func GetAge(ctx context.Context, name string) (int, error) {
+	endpoint := "https://api.agify.io/?name=" + url.QueryEscape(name)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
 	if err != nil {
-		return 0, fmt.Errorf("Agify API request failed: %v", err)
+		return 0, fmt.Errorf("failed to build Agify API request: %v", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("Agify API request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
@@ -29,6 +40,9 @@ func GetAge(name string) (int, error) {
 		return 0, fmt.Errorf("failed to parse Agify API response: %v", err)
 	}
 
+	if result["age"] == nil {
+		return 0, ErrNoPrediction
+	}
 	age, ok := result["age"].(float64)
 	if !ok {
 		return 0, fmt.Errorf("invalid or missing 'age' in Agify API response")
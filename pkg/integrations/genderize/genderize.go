@@ -1,17 +1,28 @@
 package genderize
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 )
 
-func GetGender(name string) (string, error) {
-	url := fmt.Sprintf("https://api.genderize.io/?name=%s", name)
-	resp, err := http.Get(url)
+// ErrNoPrediction возвращается, если Genderize не может предсказать пол по имени
+var ErrNoPrediction = errors.New("no prediction for name")
+
+func GetGender(ctx context.Context, name string) (string, error) {
+	endpoint := "https://api.genderize.io/?name=" + url.QueryEscape(name)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
 	if err != nil {
-		return "", fmt.Errorf("Genderize API request failed: %v", err)
+		return "", fmt.Errorf("failed to build Genderize API request: %v", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("Genderize API request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
@@ -29,6 +40,9 @@ func GetGender(name string) (string, error) {
 		return "", fmt.Errorf("failed to parse Genderize API response: %v", err)
 	}
 
+	if result["gender"] == nil {
+		return "", ErrNoPrediction
+	}
 	gender, ok := result["gender"].(string)
 	if !ok {
 		return "", fmt.Errorf("invalid or missing 'gender' in Genderize API response")
@@ -1,17 +1,28 @@
 package nationalize
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 )
 
-func GetNationality(name string) (string, error) {
-	url := fmt.Sprintf("https://api.nationalize.io/?name=%s", name)
-	resp, err := http.Get(url)
+// ErrNoPrediction возвращается, если Nationalize не может предсказать национальность по имени
+var ErrNoPrediction = errors.New("no prediction for name")
+
+func GetNationality(ctx context.Context, name string) (string, error) {
+	endpoint := "https://api.nationalize.io/?name=" + url.QueryEscape(name)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to build Nationalize API request: %v", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("Nationalize API request failed: %v", err)
+		return "", fmt.Errorf("Nationalize API request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
@@ -30,9 +41,12 @@ func GetNationality(name string) (string, error) {
 	}
 
 	countries, ok := result["country"].([]interface{})
-	if !ok || len(countries) == 0 {
+	if !ok {
 		return "", fmt.Errorf("no valid country data found in Nationalize API response")
 	}
+	if len(countries) == 0 {
+		return "", ErrNoPrediction
+	}
 
 	first := countries[0].(map[string]interface{})
 	cid, ok := first["country_id"].(string)
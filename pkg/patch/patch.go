@@ -0,0 +1,135 @@
+package patch
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Operation - одна операция JSON Patch (RFC 6902)
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// MergePatch применяет JSON Merge Patch (RFC 7396) к документу и возвращает новый документ.
+// Исходный документ не изменяется.
+func MergePatch(doc map[string]any, patch []byte) (map[string]any, error) {
+	var p any
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, fmt.Errorf("invalid merge patch: %v", err)
+	}
+	obj, ok := p.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("merge patch must be a JSON object")
+	}
+	return mergeObject(doc, obj), nil
+}
+
+func mergeObject(target, patch map[string]any) map[string]any {
+	result := make(map[string]any, len(target))
+	for k, v := range target {
+		result[k] = v
+	}
+	for k, v := range patch {
+		if v == nil {
+			delete(result, k)
+			continue
+		}
+		if nested, ok := v.(map[string]any); ok {
+			current, _ := result[k].(map[string]any)
+			result[k] = mergeObject(current, nested)
+			continue
+		}
+		result[k] = v
+	}
+	return result
+}
+
+// ApplyJSONPatch применяет JSON Patch (RFC 6902) к документу и возвращает новый документ.
+// Поддерживаются только пути к полям верхнего уровня, так как документ плоский.
+// Исходный документ не изменяется.
+func ApplyJSONPatch(doc map[string]any, patch []byte) (map[string]any, error) {
+	var ops []Operation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, fmt.Errorf("invalid JSON patch: %v", err)
+	}
+
+	result := make(map[string]any, len(doc))
+	for k, v := range doc {
+		result[k] = v
+	}
+
+	for i, op := range ops {
+		key, err := member(op.Path)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d: %v", i, err)
+		}
+
+		switch op.Op {
+		case "add", "replace":
+			if op.Op == "replace" {
+				if _, ok := result[key]; !ok {
+					return nil, fmt.Errorf("operation %d: path %q does not exist", i, op.Path)
+				}
+			}
+			value, err := decodeValue(op.Value)
+			if err != nil {
+				return nil, fmt.Errorf("operation %d: %v", i, err)
+			}
+			result[key] = value
+		case "remove":
+			if _, ok := result[key]; !ok {
+				return nil, fmt.Errorf("operation %d: path %q does not exist", i, op.Path)
+			}
+			delete(result, key)
+		case "test":
+			value, err := decodeValue(op.Value)
+			if err != nil {
+				return nil, fmt.Errorf("operation %d: %v", i, err)
+			}
+			if !reflect.DeepEqual(result[key], value) {
+				return nil, fmt.Errorf("operation %d: test failed for path %q", i, op.Path)
+			}
+		case "move", "copy":
+			from, err := member(op.From)
+			if err != nil {
+				return nil, fmt.Errorf("operation %d: %v", i, err)
+			}
+			value, ok := result[from]
+			if !ok {
+				return nil, fmt.Errorf("operation %d: path %q does not exist", i, op.From)
+			}
+			if op.Op == "move" {
+				delete(result, from)
+			}
+			result[key] = value
+		default:
+			return nil, fmt.Errorf("operation %d: unsupported op %q", i, op.Op)
+		}
+	}
+
+	return result, nil
+}
+
+// member разбирает JSON Pointer вида "/field" и возвращает имя поля
+func member(pointer string) (string, error) {
+	if !strings.HasPrefix(pointer, "/") || strings.Count(pointer, "/") != 1 || len(pointer) == 1 {
+		return "", fmt.Errorf("unsupported path %q, only top-level fields can be patched", pointer)
+	}
+	return strings.NewReplacer("~1", "/", "~0", "~").Replace(pointer[1:]), nil
+}
+
+func decodeValue(raw json.RawMessage) (any, error) {
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("missing value")
+	}
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, fmt.Errorf("invalid value: %v", err)
+	}
+	return v, nil
+}
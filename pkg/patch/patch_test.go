@@ -0,0 +1,57 @@
+package patch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ----------------
+// Тесты MergePatch
+// ----------------
+func TestMergePatchReplacesAndRemoves(t *testing.T) {
+	doc := map[string]any{"name": "ivan", "age": float64(30), "gender": "male"}
+
+	result, err := MergePatch(doc, []byte(`{"age": 31, "gender": null}`))
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"name": "ivan", "age": float64(31)}, result)
+	assert.Equal(t, "male", doc["gender"], "source document must not be modified")
+}
+
+func TestMergePatchRejectsNonObject(t *testing.T) {
+	_, err := MergePatch(map[string]any{}, []byte(`["age"]`))
+	assert.Error(t, err)
+}
+
+// --------------------
+// Тесты ApplyJSONPatch
+// --------------------
+func TestApplyJSONPatch(t *testing.T) {
+	doc := map[string]any{"name": "ivan", "age": float64(30), "gender": "male"}
+
+	result, err := ApplyJSONPatch(doc, []byte(`[
+		{"op": "test", "path": "/name", "value": "ivan"},
+		{"op": "replace", "path": "/age", "value": 31},
+		{"op": "remove", "path": "/gender"},
+		{"op": "add", "path": "/nationality", "value": "RU"}
+	]`))
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"name": "ivan", "age": float64(31), "nationality": "RU"}, result)
+}
+
+func TestApplyJSONPatchFailedTest(t *testing.T) {
+	_, err := ApplyJSONPatch(map[string]any{"name": "ivan"}, []byte(`[{"op": "test", "path": "/name", "value": "petr"}]`))
+	assert.Error(t, err)
+}
+
+func TestApplyJSONPatchNestedPath(t *testing.T) {
+	_, err := ApplyJSONPatch(map[string]any{"name": "ivan"}, []byte(`[{"op": "remove", "path": "/name/0"}]`))
+	assert.Error(t, err)
+}
+
+func TestApplyJSONPatchReplaceMissing(t *testing.T) {
+	_, err := ApplyJSONPatch(map[string]any{}, []byte(`[{"op": "replace", "path": "/age", "value": 1}]`))
+	assert.Error(t, err)
+}